@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/sync"
+	"github.com/spf13/cobra"
+)
+
+func conflicts(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "conflicts",
+		Short: "List sync conflicts that still need attention",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			found, err := sync.LoadConflicts(s.Dir())
+			if err != nil {
+				return fmt.Errorf("failed to load conflicts: %w", err)
+			}
+
+			if jsonOutput {
+				if found == nil {
+					found = []sync.Conflict{}
+				}
+				return printJSON(found)
+			}
+			if len(found) == 0 {
+				fmt.Printf("	no conflicts\n")
+				return nil
+			}
+
+			fmt.Println("Conflicts:")
+			for _, c := range found {
+				fmt.Printf("	%s (%s, %s)\n", c.Name(), c.Kind, c.DetectedAt.Format("2006-01-02 15:04"))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.AddCommand(showConflict(s))
+	cmd.AddCommand(resolveConflict(s))
+
+	return &cmd
+}
+
+func showConflict(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "show [name]",
+		Short: "Show both sides of a conflict",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := findConflict(s, args[0])
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(c)
+			}
+			for _, side := range []struct {
+				label string
+				note  *local.Note
+			}{{"Base", c.Base}, {"Local", c.Local}, {"Remote", c.Remote}} {
+				if side.note == nil || !side.note.DeletedAt.IsZero() {
+					fmt.Printf("\n%s: (deleted or unknown)\n", side.label)
+					continue
+				}
+				fmt.Printf("\n%s: %s\n%s\n", side.label, side.note.Name, side.note.Content)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func resolveConflict(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "resolve [name]",
+		Short: "Mark a conflict as resolved once the note has been fixed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := findConflict(s, args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := sync.MarkResolved(s.Dir(), c.NoteID); err != nil {
+				return fmt.Errorf("failed to resolve conflict: %w", err)
+			}
+
+			return done(c, "%s marked as resolved\n", c.Name())
+		},
+	}
+
+	return &cmd
+}
+
+func findConflict(s *local.Store, name string) (sync.Conflict, error) {
+	found, err := sync.LoadConflicts(s.Dir())
+	if err != nil {
+		return sync.Conflict{}, fmt.Errorf("failed to load conflicts: %w", err)
+	}
+
+	name = strings.TrimSpace(name)
+	for _, c := range found {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+
+	return sync.Conflict{}, fmt.Errorf("%w a conflict for note: %s", local.ErrNotFound, name)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+// errEmptyBuffer is returned by openEditor when the user saved an empty file,
+// which is how a note gets abandoned.
+var errEmptyBuffer = errors.New("empty note, nothing saved")
+
+// editorCommand returns the user's editor from $VISUAL or $EDITOR, split into the
+// program and its arguments so values like "code --wait" work.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// openEditor lets the user edit text in their editor through a temp file and returns
+// what they saved, trimmed, and the temp file's path. The caller removes the file once
+// the text is saved, so nothing is lost when saving fails.
+func openEditor(name, text string) (string, string, error) {
+	tmp, err := os.CreateTemp("", "biji-"+strings.ReplaceAll(name, "/", "_")+"-*.md")
+	if err != nil {
+		return "", "", fmt.Errorf("error creating temp file: %w", err)
+	}
+	path := tmp.Name()
+
+	if _, err := tmp.WriteString(text); err != nil {
+		tmp.Close()
+		os.Remove(path)
+		return "", "", fmt.Errorf("error writing temp file: %w", err)
+	}
+	tmp.Close()
+
+	editor := editorCommand()
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(path)
+		return "", "", fmt.Errorf("error running %s: %w", editor[0], err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		os.Remove(path)
+		return "", "", fmt.Errorf("error reading temp file: %w", err)
+	}
+
+	edited := strings.TrimSpace(string(data))
+	if edited == "" {
+		os.Remove(path)
+		return "", "", errEmptyBuffer
+	}
+
+	return edited, path, nil
+}
+
+func editNote(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "edit [name]",
+		Short: "Edit a note in $VISUAL or $EDITOR",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := resolveNote(s.Notes, args[0])
+			if err != nil {
+				return err
+			}
+
+			note, err := s.GetNoteFromID(id)
+			if err != nil {
+				return err
+			}
+
+			content, path, err := openEditor(note.Name, note.Content)
+			if errors.Is(err, errEmptyBuffer) {
+				return done(newNoteEntry(note), "Empty note, left as it was. Use biji delete to remove it\n")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to edit note: %w", err)
+			}
+
+			if content == note.Content {
+				os.Remove(path)
+				return done(newNoteEntry(note), "No changes\n")
+			}
+
+			saved, err := s.UpdateNoteContent(id, content)
+			if err != nil {
+				// Don't throw the edit away, it may have taken a while
+				return fmt.Errorf("failed to save note, your edit is kept in %s: %w", path, err)
+			}
+			os.Remove(path)
+
+			return done(newNoteEntry(saved), "Note saved\n")
+		},
+	}
+
+	return &cmd
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeEditor points $EDITOR at a script that saves what it was given in seen and
+// replaces it with $FAKE_EDITOR_TEXT. Temp files go to tmp so tests can see they're gone.
+func fakeEditor(t *testing.T) (seen, tmp string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("The fake editor is a shell script")
+	}
+
+	dir := t.TempDir()
+	seen = filepath.Join(dir, "seen")
+	script := filepath.Join(dir, "editor.sh")
+	body := "#!/bin/sh\ncat \"$1\" > \"$FAKE_EDITOR_SEEN\"\nprintf '%s' \"$FAKE_EDITOR_TEXT\" > \"$1\"\n[ -z \"$FAKE_EDITOR_FAIL\" ]\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("Failed to write fake editor: %v", err)
+	}
+
+	tmp = t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("VISUAL", "")
+	// Run through sh so the editor's arguments get split like "code --wait"
+	t.Setenv("EDITOR", "/bin/sh "+script)
+	t.Setenv("FAKE_EDITOR_SEEN", seen)
+	t.Setenv("FAKE_EDITOR_FAIL", "")
+	return seen, tmp
+}
+
+func TestEditor_NewAndEdit(t *testing.T) {
+	seen, tmp := fakeEditor(t)
+	store := newTestStore(t)
+
+	noTempFiles := func(step string) {
+		if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+			t.Errorf("%s: expected the temp file removed, found %s", step, entries[0].Name())
+		}
+	}
+
+	// Without content from args, --file or stdin, new opens the editor
+	t.Setenv("FAKE_EDITOR_TEXT", "  written in the editor\n")
+	if code, _, stderr := runCmd(t, store, "", "new", "plan"); code != 0 {
+		t.Fatalf("Expected new to succeed, got exit code %d: %s", code, stderr)
+	}
+	id, err := store.FindNoteID("plan")
+	if err != nil {
+		t.Fatalf("Expected the note to be created: %v", err)
+	}
+	note, _ := store.GetNoteFromID(id)
+	if note.Content != "written in the editor" {
+		t.Errorf("Expected the editor's text, trimmed, got %q", note.Content)
+	}
+	noTempFiles("new")
+
+	t.Setenv("FAKE_EDITOR_TEXT", "")
+	if code, out, _ := runCmd(t, store, "", "new", "abandoned"); code != 0 || !strings.Contains(out, "nothing saved") {
+		t.Errorf("Expected an empty buffer to save nothing, got exit code %d and %q", code, out)
+	}
+	if _, err := store.FindNoteID("abandoned"); err == nil {
+		t.Error("Expected no note from an empty buffer")
+	}
+	noTempFiles("empty new")
+
+	// edit starts the editor on the note's content
+	t.Setenv("FAKE_EDITOR_TEXT", "edited")
+	if code, _, stderr := runCmd(t, store, "", "edit", "plan"); code != 0 {
+		t.Fatalf("Expected edit to succeed, got exit code %d: %s", code, stderr)
+	}
+	if data, _ := os.ReadFile(seen); string(data) != "written in the editor" {
+		t.Errorf("Expected the editor to open the note's content, got %q", data)
+	}
+	edited, _ := store.GetNoteFromID(id)
+	if edited.Content != "edited" || edited.Version != note.Version+1 {
+		t.Errorf("Expected the edit saved as a new version, got %q at version %d", edited.Content, edited.Version)
+	}
+	noTempFiles("edit")
+
+	for _, tt := range []struct {
+		text, fail, want string
+		code             int
+	}{
+		{"edited", "", "No changes", 0},
+		{"", "", "left as it was", 0},
+		{"thrown away", "yes", "", exitFailure},
+	} {
+		t.Setenv("FAKE_EDITOR_TEXT", tt.text)
+		t.Setenv("FAKE_EDITOR_FAIL", tt.fail)
+		code, out, stderr := runCmd(t, store, "", "edit", "plan")
+		if code != tt.code || !strings.Contains(out, tt.want) {
+			t.Errorf("Editor writing %q: expected exit code %d and %q, got %d and %q %q", tt.text, tt.code, tt.want, code, out, stderr)
+		}
+		unchanged, _ := store.GetNoteFromID(id)
+		if unchanged.Content != "edited" || unchanged.Version != edited.Version {
+			t.Errorf("Editor writing %q: expected the note left alone, got %q at version %d", tt.text, unchanged.Content, unchanged.Version)
+		}
+		noTempFiles("edit writing " + tt.text)
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func history(s *local.Store) *cobra.Command {
+	var from, to int
+
+	cmd := cobra.Command{
+		Use:   "history [name]",
+		Short: "List the versions of a note, or diff two of them with --from",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSpace(args[0])
+
+			id, err := resolveNote(s.Notes, name)
+			if err != nil {
+				return err
+			}
+
+			note, err := s.GetNoteFromID(id)
+			if err != nil {
+				return err
+			}
+			revisions, err := s.History(id)
+			if err != nil {
+				return fmt.Errorf("failed to retrieve history: %w", err)
+			}
+			current := revisions[len(revisions)-1]
+
+			if from == 0 {
+				if jsonOutput {
+					return printJSON(revisions)
+				}
+
+				fmt.Printf("History of %s:\n", note.Path())
+				for i := len(revisions) - 1; i >= 0; i-- {
+					rev := revisions[i]
+					marker := ""
+					if rev.Version == current.Version {
+						marker = " (current)"
+					}
+					fmt.Printf("	v%d  %s  %s%s\n", rev.Version, rev.ModifiedAt.Local().Format("2006-01-02 15:04"), rev.Name, marker)
+				}
+				return nil
+			}
+
+			if to == 0 {
+				to = current.Version
+			}
+
+			diff, err := s.DiffRevisions(id, from, to)
+			if err != nil {
+				return fmt.Errorf("failed to diff versions: %w", err)
+			}
+
+			if jsonOutput {
+				lines := make([]string, len(diff))
+				for i, line := range diff {
+					lines[i] = fmt.Sprint(line)
+				}
+				return printJSON(map[string]any{"from": from, "to": to, "diff": lines})
+			}
+
+			fmt.Printf("--- v%d\n+++ v%d\n", from, to)
+			for _, line := range diff {
+				fmt.Println(line)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&from, "from", 0, "version to diff from")
+	cmd.Flags().IntVar(&to, "to", 0, "version to diff to (default current)")
+
+	return &cmd
+}
+
+func revert(s *local.Store) *cobra.Command {
+	var to int
+
+	cmd := cobra.Command{
+		Use:   "revert [name] --to N",
+		Short: "Restore an older version of a note",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSpace(args[0])
+
+			id, err := resolveNote(s.Notes, name)
+			if err != nil {
+				return err
+			}
+
+			note, err := s.RevertNote(id, to)
+			if err != nil {
+				return fmt.Errorf("failed to revert note: %w", err)
+			}
+
+			return done(newNoteEntry(note), "%s reverted to v%d, saved as v%d\n", note.Name, to, note.Version)
+		},
+	}
+
+	cmd.Flags().IntVar(&to, "to", 0, "version to restore")
+	cmd.MarkFlagRequired("to")
+
+	return &cmd
+}
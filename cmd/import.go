@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func importNotes(s *local.Store) *cobra.Command {
+	var collision string
+	var from string
+
+	cmd := cobra.Command{
+		Use:   "import [path]",
+		Short: "Import notes from a folder or zip of Markdown files, or another app's export",
+		Long: `Import notes from a folder or zip of Markdown and text files, like a biji export.
+
+With --from, import another app's export instead:
+  obsidian    an Obsidian vault folder
+  joplin      a Joplin .jex archive
+  simplenote  a Simplenote notes.json, or the zip it comes in`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := local.ParseCollisionPolicy(collision)
+			if err != nil {
+				return usageError{err}
+			}
+
+			result, err := s.ImportFrom(from, args[0], policy)
+			if err != nil {
+				return fmt.Errorf("import failed: %w", err)
+			}
+
+			if jsonOutput {
+				failed := make([]string, len(result.Failed))
+				for i, err := range result.Failed {
+					failed[i] = err.Error()
+				}
+				return printJSON(map[string]any{
+					"imported":    result.Imported,
+					"updated":     result.Updated,
+					"renamed":     result.Renamed,
+					"overwritten": result.Overwritten,
+					"skipped":     result.Skipped,
+					"failed":      failed,
+				})
+			}
+
+			say("Imported %d notes\n", result.Imported)
+			if result.Updated > 0 {
+				say("	%d existing notes updated from newer files\n", result.Updated)
+			}
+			if result.Renamed > 0 {
+				say("	%d imported under a new name\n", result.Renamed)
+			}
+			if result.Overwritten > 0 {
+				say("	%d existing notes overwritten\n", result.Overwritten)
+			}
+			if result.Skipped > 0 {
+				say("	%d skipped, unchanged or the name was taken\n", result.Skipped)
+			}
+			// Files that failed are worth seeing even with --quiet
+			for _, err := range result.Failed {
+				fmt.Fprintf(os.Stderr, "	failed: %v\n", err)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "markdown", "format to import: "+strings.Join(local.ImportFormats(), ", "))
+	cmd.Flags().StringVar(&collision, "on-collision", "skip", "what to do when a note name is taken: skip, rename or overwrite")
+
+	return &cmd
+}
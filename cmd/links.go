@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func backlinks(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "backlinks [name]",
+		Short: "List the notes that link to a note with [[name]]",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := resolveNote(s.Notes, args[0])
+			if err != nil {
+				return err
+			}
+
+			notes, err := s.Backlinks(id)
+			if err != nil {
+				return fmt.Errorf("failed to find backlinks: %w", err)
+			}
+			if jsonOutput {
+				return printJSON(noteEntries(notes))
+			}
+
+			if len(notes) == 0 {
+				fmt.Printf("	no backlinks\n")
+				return nil
+			}
+
+			fmt.Println("Linked from:")
+			for _, note := range notes {
+				fmt.Printf("	%s\n", note.Path())
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func links(s *local.Store) *cobra.Command {
+	var broken bool
+
+	cmd := cobra.Command{
+		Use:   "links [name]",
+		Short: "List the [[links]] in a note, or every broken link with --broken",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !broken && len(args) == 0 {
+				return cmd.Help()
+			}
+			if broken {
+				found := s.BrokenLinks()
+				if jsonOutput {
+					type brokenLink struct {
+						Path   string `json:"path"`
+						Target string `json:"target"`
+					}
+					entries := make([]brokenLink, 0, len(found))
+					for _, b := range found {
+						entries = append(entries, brokenLink{b.Note.Path(), b.Link.Target})
+					}
+					return printJSON(entries)
+				}
+				if len(found) == 0 {
+					fmt.Printf("	no broken links\n")
+					return nil
+				}
+
+				fmt.Println("Broken links:")
+				for _, b := range found {
+					fmt.Printf("	%s -> [[%s]]\n", b.Note.Path(), b.Link.Target)
+				}
+				return nil
+			}
+
+			id, err := resolveNote(s.Notes, args[0])
+			if err != nil {
+				return err
+			}
+
+			found, err := s.Links(id)
+			if err != nil {
+				return fmt.Errorf("failed to find links: %w", err)
+			}
+			if jsonOutput {
+				type noteLink struct {
+					Target string `json:"target"`
+					Broken bool   `json:"broken"`
+				}
+				entries := make([]noteLink, 0, len(found))
+				for _, link := range found {
+					_, err := s.FindNoteID(link.Target)
+					entries = append(entries, noteLink{link.Target, err != nil})
+				}
+				return printJSON(entries)
+			}
+
+			if len(found) == 0 {
+				fmt.Printf("	no links\n")
+				return nil
+			}
+
+			fmt.Println("Links to:")
+			for _, link := range found {
+				marker := ""
+				if _, err := s.FindNoteID(link.Target); err != nil {
+					marker = " (broken)"
+				}
+				fmt.Printf("	%s%s\n", link.Target, marker)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&broken, "broken", false, "list every link that doesn't lead to a note")
+
+	return &cmd
+}
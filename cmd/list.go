@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func listNotes(s *local.Store) *cobra.Command {
+	var tag, sortBy, since, before, format string
+	var long bool
+
+	cmd := cobra.Command{
+		Use:   "list",
+		Short: "list currently saved notes, pinned ones first",
+		Long: `List currently saved notes, pinned ones first.
+
+--since and --before take a date like 2025-01-31, a time like 2025-01-31T09:00:00Z,
+or how long ago like 36h, 7d or 2w, and compare it to when a note was last modified.
+
+--format prints every note with a Go template, given the fields --json prints:
+  biji list --format '{{.ID}} {{.Path}} {{.Words}}'
+  biji list --format '{{.Modified.Format "2006-01-02"}} {{.Name}}'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if jsonOutput && (long || format != "") {
+				return usageError{errors.New("--json can't be used with --long or --format")}
+			}
+			if long && format != "" {
+				return usageError{errors.New("--long can't be used with --format")}
+			}
+
+			order, err := local.ParseSortOrder(sortBy)
+			if err != nil {
+				return usageError{err}
+			}
+
+			var from, until time.Time
+			if since != "" {
+				if from, err = parseListTime(since); err != nil {
+					return usageError{err}
+				}
+			}
+			if before != "" {
+				if until, err = parseListTime(before); err != nil {
+					return usageError{err}
+				}
+			}
+
+			var tmpl *template.Template
+			if format != "" {
+				if tmpl, err = template.New("list").Parse(format); err != nil {
+					return usageError{fmt.Errorf("bad --format template: %w", err)}
+				}
+			}
+
+			notes, err := s.GetNotes()
+			if err != nil {
+				return fmt.Errorf("failed to retrieve notes: %w", err)
+			}
+			if tag != "" {
+				notes = s.NotesWithTag(tag)
+			}
+
+			var listed []local.Note
+			for _, note := range notes {
+				if !from.IsZero() && note.ModifiedAt.Before(from) {
+					continue
+				}
+				if !until.IsZero() && !note.ModifiedAt.Before(until) {
+					continue
+				}
+				listed = append(listed, note)
+			}
+			local.SortNotes(listed, order)
+
+			switch {
+			case jsonOutput:
+				return printJSON(noteEntries(listed))
+			case tmpl != nil:
+				for _, note := range listed {
+					if err := tmpl.Execute(os.Stdout, newNoteEntry(note)); err != nil {
+						return fmt.Errorf("failed to list notes: %w", err)
+					}
+					fmt.Println()
+				}
+			case len(listed) == 0:
+				fmt.Printf("	no notes\n")
+			case long:
+				printNoteTable(listed)
+			default:
+				printNoteNames(listed)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tag, "tag", "", "only list notes with this tag")
+	cmd.Flags().StringVar(&sortBy, "sort", "modified", "order of the notes that aren't pinned: modified, created, name or size")
+	cmd.Flags().StringVar(&since, "since", "", "only list notes modified since this date or how long ago, like 2025-01-31 or 7d")
+	cmd.Flags().StringVar(&before, "before", "", "only list notes last modified before this date or how long ago")
+	cmd.Flags().BoolVarP(&long, "long", "l", false, "show a table with when notes were created and modified and their word counts")
+	cmd.Flags().StringVar(&format, "format", "", "print every note with a Go template, like '{{.ID}} {{.Path}}'")
+
+	return &cmd
+}
+
+// printNoteNames prints the notes under a Pinned: and a Notes: heading.
+func printNoteNames(notes []local.Note) {
+	heading := ""
+	for _, note := range notes {
+		next := "Notes:"
+		if note.Pinned {
+			next = "Pinned:"
+		}
+		if next != heading {
+			heading = next
+			fmt.Println(heading)
+		}
+
+		fmt.Printf("	%s\n", listName(note))
+	}
+}
+
+// printNoteTable prints the notes as a table, pinned ones marked with a *.
+func printNoteTable(notes []local.Note) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tCREATED\tMODIFIED\tWORDS")
+	for _, note := range notes {
+		pin := " "
+		if note.Pinned {
+			pin = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%d\n",
+			pin,
+			listName(note),
+			note.CreatedAt.Local().Format("2006-01-02 15:04"),
+			note.ModifiedAt.Local().Format("2006-01-02 15:04"),
+			note.Words(),
+		)
+	}
+	w.Flush()
+}
+
+func listName(note local.Note) string {
+	if note.Favorite {
+		return note.Path() + " ★"
+	}
+	return note.Path()
+}
+
+// parseListTime reads a --since or --before value: a date, a date and time, or how long
+// ago in hours, days or weeks.
+func parseListTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if n, ok := strings.CutSuffix(value, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil {
+			return time.Now().AddDate(0, 0, -days), nil
+		}
+	}
+	if n, ok := strings.CutSuffix(value, "w"); ok {
+		if weeks, err := strconv.Atoi(n); err == nil {
+			return time.Now().AddDate(0, 0, -7*weeks), nil
+		}
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-ago), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04", time.DateTime} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("could not read %q as a date, use something like 2025-01-31 or 7d", value)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+)
+
+func TestParseListTime(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"36h", now.Add(-36 * time.Hour)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{" 2w ", now.AddDate(0, 0, -14)},
+		{"2025-01-31", time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)},
+		{"2025-01-31 09:30", time.Date(2025, 1, 31, 9, 30, 0, 0, time.Local)},
+		{"2025-01-31 09:30:15", time.Date(2025, 1, 31, 9, 30, 15, 0, time.Local)},
+		{"2025-01-31T09:00:00Z", time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseListTime(tt.value)
+		if err != nil {
+			t.Errorf("parseListTime(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if d := got.Sub(tt.want).Abs(); d > time.Minute {
+			t.Errorf("parseListTime(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "7", "d", "-", "2025-13-01", "31/01/2025"} {
+		if _, err := parseListTime(value); err == nil {
+			t.Errorf("Expected parseListTime(%q) to fail", value)
+		}
+	}
+}
+
+func TestList_Flags(t *testing.T) {
+	// Imported files keep their modification times
+	dir := t.TempDir()
+	for _, file := range []struct {
+		name, content string
+		age           time.Duration
+	}{
+		{"old.md", "a few words here", 10 * 24 * time.Hour},
+		{"recent.md", "the most words of them all, by some way", 2 * 24 * time.Hour},
+		{"new.md", "one", 0},
+	} {
+		path := filepath.Join(dir, file.name)
+		if err := os.WriteFile(path, []byte(file.content), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file.name, err)
+		}
+		modified := time.Now().Add(-file.age)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatalf("Failed to set the time of %s: %v", file.name, err)
+		}
+	}
+
+	store := newTestStore(t)
+	if _, err := store.Import(dir, local.SkipExisting); err != nil {
+		t.Fatalf("Failed to import notes: %v", err)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--since", "5d"}, "new\nrecent\n"},
+		{[]string{"--before", "5d"}, "old\n"},
+		{[]string{"--since", "12d", "--before", "1d"}, "recent\nold\n"},
+		{[]string{"--before", "2000-01-01"}, ""},
+		{[]string{"--sort", "size"}, "recent\nold\nnew\n"},
+		{[]string{"--sort", "name"}, "new\nold\nrecent\n"},
+		{[]string{"--sort", "size", "--format", "{{.Name}} {{.Words}}"}, "recent 9\nold 4\nnew 1\n"},
+	}
+
+	for _, tt := range tests {
+		args := append([]string{"list", "--format", "{{.Name}}"}, tt.args...)
+		code, out, stderr := runCmd(t, store, "", args...)
+		if code != 0 {
+			t.Errorf("%v: expected success, got exit code %d: %s", tt.args, code, stderr)
+			continue
+		}
+		if out != tt.want {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.want, out)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--since", "someday"},
+		{"--before", "2025-02-30"},
+		{"--sort", "color"},
+		{"--format", "{{.Name"},
+		{"--format", "{{.Name}}", "--long"},
+		{"--json", "--long"},
+	} {
+		if code, _, stderr := runCmd(t, store, "", append([]string{"list"}, args...)...); code != exitUsage {
+			t.Errorf("%v: expected a usage error, got exit code %d: %s", args, code, stderr)
+		}
+	}
+
+	code, out, _ := runCmd(t, store, "", "list", "--since", "5d", "--long")
+	if code != 0 || !strings.Contains(out, "NAME") || !strings.Contains(out, "recent") || strings.Contains(out, "old") {
+		t.Errorf("Expected a table of the recent notes, got exit code %d and %q", code, out)
+	}
+}
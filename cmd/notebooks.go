@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func notebook(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "notebook",
+		Short: "Create, move and list notebooks",
+	}
+
+	cmd.AddCommand(createNotebook(s))
+	cmd.AddCommand(moveToNotebook(s))
+	cmd.AddCommand(listNotebooks(s))
+
+	return &cmd
+}
+
+func createNotebook(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "create [path]",
+		Short: "Create a notebook, like work/projects",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notebook, err := s.CreateNotebook(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to create notebook: %w", err)
+			}
+
+			return done(map[string]string{"notebook": notebook}, "Notebook %s created\n", notebook)
+		},
+	}
+
+	return &cmd
+}
+
+func moveToNotebook(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "move [note or notebook] [notebook]",
+		Short: "Move a note or a whole notebook into another notebook, / is the top level",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dest, err := local.CleanNotebook(args[1])
+			if err != nil {
+				return usageError{err}
+			}
+
+			notebooks, err := s.Notebooks()
+			if err != nil {
+				return fmt.Errorf("failed to load notebooks: %w", err)
+			}
+
+			// A notebook goes inside dest and keeps its own name
+			src, _ := local.CleanNotebook(args[0])
+			if slices.Contains(notebooks, src) {
+				to := src[strings.LastIndex(src, "/")+1:]
+				if dest != "" {
+					to = dest + "/" + to
+				}
+				if err := s.MoveNotebook(src, to); err != nil {
+					return fmt.Errorf("failed to move notebook: %w", err)
+				}
+
+				return done(map[string]string{"notebook": src, "movedTo": to}, "Notebook %s moved to %s\n", src, to)
+			}
+
+			id, err := resolveNote(s.Notes, args[0])
+			if err != nil {
+				return err
+			}
+
+			note, err := s.MoveNote(id, dest)
+			if err != nil {
+				return fmt.Errorf("failed to move note: %w", err)
+			}
+
+			return done(newNoteEntry(note), "Note moved to %s\n", note.Path())
+		},
+	}
+
+	return &cmd
+}
+
+func listNotebooks(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "list [notebook]",
+		Short: "Show the notebook tree with its notes",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var root string
+			if len(args) == 1 {
+				var err error
+				if root, err = local.CleanNotebook(args[0]); err != nil {
+					return usageError{err}
+				}
+			}
+
+			notebooks, err := s.Notebooks()
+			if err != nil {
+				return fmt.Errorf("failed to load notebooks: %w", err)
+			}
+			if root != "" && !slices.Contains(notebooks, root) {
+				return fmt.Errorf("%w notebook: %s", local.ErrNotFound, root)
+			}
+
+			if jsonOutput {
+				type notebookEntry struct {
+					Notebook string   `json:"notebook"`
+					Notes    []string `json:"notes"`
+				}
+				// The notebook itself, then the ones inside it
+				listed := []string{root}
+				for _, notebook := range notebooks {
+					if notebook != root && (root == "" || strings.HasPrefix(notebook, root+"/")) {
+						listed = append(listed, notebook)
+					}
+				}
+
+				entries := []notebookEntry{}
+				for _, notebook := range listed {
+					names := []string{}
+					for _, note := range s.NotesIn(notebook, false) {
+						names = append(names, note.Name)
+					}
+					entries = append(entries, notebookEntry{notebook, names})
+				}
+				return printJSON(entries)
+			}
+
+			printNotes := func(notebook string, depth int) {
+				for _, note := range s.NotesIn(notebook, false) {
+					fmt.Printf("%s%s\n", strings.Repeat("	", depth), note.Name)
+				}
+			}
+
+			if root == "" {
+				fmt.Println("Notes:")
+			} else {
+				fmt.Printf("%s:\n", root)
+			}
+			for _, notebook := range notebooks {
+				if notebook == root || (root != "" && !strings.HasPrefix(notebook, root+"/")) {
+					continue
+				}
+				rel := strings.TrimPrefix(strings.TrimPrefix(notebook, root), "/")
+				depth := strings.Count(rel, "/") + 1
+				fmt.Printf("%s%s/\n", strings.Repeat("	", depth), rel[strings.LastIndex(rel, "/")+1:])
+				printNotes(notebook, depth+1)
+			}
+			printNotes(root, 1)
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
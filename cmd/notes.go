@@ -1,89 +1,167 @@
 package cmd
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/tui"
 	"github.com/spf13/cobra"
 )
 
-// TODO: This logic is broken... need to create a switch case to get desired outcome.
-// it can probably get done via the if or else logic but it's lazy and not pretty and
-// easy to read.
-// DO THE SAME FOR DELETION MAYBE????
 func newNote(s *local.Store) *cobra.Command {
+	var notebook, file string
+	var appendTo bool
+
 	cmd := cobra.Command{
 		Use:   "new [name] [content]",
-		Short: "Create a new note",
+		Short: "Create a new note, content comes from the args, --file, stdin or $VISUAL/$EDITOR",
+		Args:  cobra.MaximumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var name, content string
-			reader := bufio.NewReader(os.Stdin)
+			hasContent := false
 
-			if len(args) == 2 {
+			if len(args) > 0 {
 				name = args[0]
+			}
 
-				content = strings.TrimSpace(strings.ReplaceAll(args[1], "\\n", "\n"))
-			} else {
-				fmt.Print("Name: ")
+			switch {
+			case len(args) == 2:
+				content = strings.ReplaceAll(args[1], "\\n", "\n")
+				hasContent = true
+			case file != "":
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return fmt.Errorf("failed to read file: %w", err)
+				}
+				content = string(data)
+				hasContent = true
 
-				rawInput, err := reader.ReadString('\n')
+				if name == "" {
+					name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+				}
+			case stdinPiped():
+				// cat meeting.md | biji new standup
+				if name == "" {
+					return usageError{errors.New("a name is needed when content comes from stdin")}
+				}
+				data, err := io.ReadAll(os.Stdin)
 				if err != nil {
-					log.Fatalf("Failed to read name: %v", err)
+					return fmt.Errorf("failed to read stdin: %w", err)
 				}
-				name = strings.TrimSpace(rawInput)
+				content = string(data)
+				hasContent = true
+			}
+			content = strings.TrimSpace(content)
+
+			if name == "" {
+				var err error
+				if name, err = prompt("Name: "); err != nil {
+					return err
+				}
+			}
+			if name == "" {
+				return usageError{errors.New("note name is empty")}
+			}
+			if err := local.CheckName(name); err != nil {
+				return usageError{err}
+			}
 
-				fmt.Print("Content: ")
-				rawInput, err = reader.ReadString('\n')
+			// Long notes get written in the editor
+			var path string
+			if !hasContent {
+				var err error
+				content, path, err = openEditor(name, "")
+				if errors.Is(err, errEmptyBuffer) {
+					return done(nil, "Empty note, nothing saved\n")
+				}
 				if err != nil {
-					log.Fatalf("Failed to read content: %v", err)
+					return fmt.Errorf("failed to write note: %w", err)
+				}
+			}
+			keepText := func(err error) error {
+				if path != "" {
+					return fmt.Errorf("note creation failed, your text is kept in %s: %w", path, err)
 				}
-				content = strings.ReplaceAll(rawInput, "\\n", "\n")
-				content = strings.TrimSpace(content)
+				return fmt.Errorf("note creation failed: %w", err)
 			}
 
-			_, err := s.AddNote(name, content)
-			if err != nil {
-				log.Fatalf("Note creation failed: %v", err)
+			if appendTo {
+				notePath := name
+				if notebook != "" {
+					notePath = strings.Trim(notebook, "/") + "/" + name
+				}
+
+				// A missing note is created below
+				if id, err := s.FindNoteID(notePath); err == nil {
+					note, err := s.AppendNoteContent(id, content)
+					if err != nil {
+						return keepText(err)
+					}
+					if path != "" {
+						os.Remove(path)
+					}
+
+					return done(newNoteEntry(note), "Note appended sucessfully\n")
+				}
 			}
 
-			fmt.Println("Note created sucessfully")
+			note, err := s.AddNoteTo(notebook, name, content)
+			if err != nil {
+				return keepText(err)
+			}
+			if path != "" {
+				os.Remove(path)
+			}
 
-			return nil
+			return done(newNoteEntry(*note), "Note created sucessfully\n")
 		},
 	}
 
+	cmd.Flags().StringVarP(&notebook, "notebook", "n", "", "notebook to put the note in, like work/projects")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "read the content from a file, named after the file unless a name is given")
+	cmd.Flags().BoolVarP(&appendTo, "append", "a", false, "add the content to the end of the note if it already exists")
+
 	return &cmd
 }
 
+// stdinPiped reports whether stdin is a pipe or file rather than a terminal.
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
 func deleteNote(s *local.Store) *cobra.Command {
 	cmd := cobra.Command{
 		Use:   "delete [name], [name], ...",
-		Short: "Delete a note by name",
+		Short: "Move a note to the trash by name",
+		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 {
-				fmt.Println("No note names provided")
-				return nil
-			}
+			var deleted []local.Note
 			for i := range args {
 				name := strings.TrimSpace(args[i])
-				id, err := s.FindNoteID(s.Notes, name)
+				id, err := resolveNote(s.Notes, name)
 				if err != nil {
-					log.Fatalf("Error deleting note: %v", err)
+					return err
 				}
 
-				err = s.DeleteNote(id)
-				if err != nil {
-					log.Fatalf("Error deleting note: %v", err)
+				note, _ := s.GetNoteFromID(id)
+				if err := s.DeleteNote(id); err != nil {
+					return fmt.Errorf("error deleting %s: %w", name, err)
 				}
+				deleted = append(deleted, note)
 			}
 
-			fmt.Println("Note deleted successfully")
-
-			return nil
+			return done(noteEntries(deleted), "Note moved to the trash, see biji trash\n")
 		},
 	}
 
@@ -94,33 +172,28 @@ func updateNoteName(s *local.Store) *cobra.Command {
 	cmd := cobra.Command{
 		Use:   "rename [currName] [newName]",
 		Short: "rename note with current name [name]",
+		Args:  cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			reader := bufio.NewReader(os.Stdin)
 			currName := strings.TrimSpace(args[0])
 			var newName string
 
-			if len(args) > 1 {
-				newName = strings.TrimSpace(args[1])
-			} else {
-				fmt.Print("New Name: ")
-				rawInput, err := reader.ReadString('\n')
-				if err != nil {
-					log.Fatalf("Failed to read new name input: %v", err)
-				}
-				newName = strings.TrimSpace(rawInput)
+			id, err := resolveNote(s.Notes, currName)
+			if err != nil {
+				return err
 			}
 
-			id, err := s.FindNoteID(s.Notes, currName)
-			if err != nil {
-				log.Fatalf("Could not find note: %v", err)
+			if len(args) > 1 {
+				newName = strings.TrimSpace(args[1])
+			} else if newName, err = prompt("New Name: "); err != nil {
+				return err
 			}
 
-			_, err = s.UpdateNoteName(id, newName)
+			note, err := s.UpdateNoteName(id, newName)
 			if err != nil {
-				log.Fatalf("Failed to update note name: %v", err)
+				return fmt.Errorf("failed to rename note: %w", err)
 			}
 
-			return nil
+			return done(newNoteEntry(note), "%s renamed to %s\n", currName, note.Name)
 		},
 	}
 
@@ -128,101 +201,166 @@ func updateNoteName(s *local.Store) *cobra.Command {
 }
 
 func viewNote(s *local.Store) *cobra.Command {
+	var raw bool
+
 	cmd := cobra.Command{
 		Use:   "view [name]",
-		Short: "View note contents",
+		Short: "View note contents, rendered for the terminal unless --raw",
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := strings.TrimSpace(args[0])
 
-			id, err := s.FindNoteID(s.Notes, name)
+			id, err := resolveNote(s.Notes, name)
 			if err != nil {
-				log.Fatalf("Could not find note: %v", err)
+				return err
 			}
 
 			note, err := s.GetNoteFromID(id)
 			if err != nil {
-				log.Fatalf("Failed to retrieve note: %v", err)
+				return err
+			}
+
+			if jsonOutput {
+				return printJSON(struct {
+					noteEntry
+					Content string `json:"content"`
+				}{newNoteEntry(note), note.Content})
 			}
 
-			fmt.Printf(
-				"\n\nNote: %s\nContent: %s\n\n",
-				note.Name,
-				note.Content,
-			)
+			if raw {
+				fmt.Print(note.Content)
+				return nil
+			}
+
+			width := viewWidth()
+			fmt.Printf("%s\n\n%s\n", viewTitle.Render(note.Path()), tui.RenderMarkdown(note.Content, width))
 
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&raw, "raw", false, "Print the Markdown as written instead of rendering it")
+
 	return &cmd
 }
 
-func listNotes(s *local.Store) *cobra.Command {
-	cmd := cobra.Command{
-		Use:   "list",
-		Short: "list currently saved notes",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			names := s.GetNoteNames()
-			if len(names) == 0 {
-				fmt.Printf("	no notes\n")
-				return nil
-			}
+// maxViewWidth keeps rendered notes readable on wide terminals.
+const maxViewWidth = 100
 
-			fmt.Println("Notes:")
-			for _, name := range names {
-				fmt.Printf("	%s\n", name)
-			}
+var viewTitle = lipgloss.NewStyle().Bold(true).Reverse(true).Padding(0, 1)
 
-			return nil
-		},
+// viewWidth is how wide view renders notes, the terminal's width up to maxViewWidth,
+// or 80 columns when stdout isn't a terminal.
+func viewWidth() int {
+	width, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || width <= 0 {
+		return 80
 	}
-
-	return &cmd
+	return min(width, maxViewWidth)
 }
 
 func export(s *local.Store) *cobra.Command {
+	var format string
+	var out string
+
 	cmd := cobra.Command{
 		Use:   "export [name] [name] ...",
-		Short: "Export designated note(s) to .md file",
+		Short: "Export designated note(s) to files, Markdown unless --format says otherwise",
+		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 {
-				fmt.Println("No note names provided")
-				return nil
+			exportFormat, err := local.ParseExportFormat(format)
+			if err != nil {
+				return usageError{err}
 			}
-			for i := range args {
-				name := strings.TrimSpace(args[i])
-				id, err := s.FindNoteID(s.Notes, name)
-				if err != nil {
-					log.Fatalf("Error exporting note: %v", err)
+			if out == "" {
+				if out, err = local.DefaultExportDir(); err != nil {
+					return err
 				}
+			}
+			if err := os.MkdirAll(out, 0o755); err != nil {
+				return fmt.Errorf("error exporting notes: %w", err)
+			}
 
-				err = s.ExportNote(id)
-				if err != nil {
-					log.Fatalf("Error exporting note: %v", err)
+			type exported struct {
+				Path string `json:"path"`
+				File string `json:"file"`
+			}
+			// Every name is resolved before anything is written
+			ids := make([]string, len(args))
+			for i, name := range args {
+				if ids[i], err = resolveNote(s.Notes, name); err != nil {
+					return err
 				}
+			}
+
+			paths, err := s.ExportNotes(ids, out, exportFormat)
+			if err != nil {
+				return err
+			}
 
-				fmt.Printf("%s successfully exported\n", name)
+			var files []exported
+			for i, filePath := range paths {
+				name := strings.TrimSpace(args[i])
+				say("%s successfully exported to %s\n", name, filePath)
+				files = append(files, exported{name, filePath})
 			}
 
+			if jsonOutput {
+				return printJSON(files)
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&format, "format", "md", "file format: md, html, print (printable html), json or txt")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "directory to export to (default ~/Documents)")
+
 	return &cmd
 }
 
 func migrate(s *local.Store) *cobra.Command {
+	var format string
+	var out string
+
 	cmd := cobra.Command{
 		Use:   "migrate",
-		Short: "Export all notes for migration",
+		Short: "Export all notes to a zip for migration",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := s.ExportAll()
+			exportFormat, err := local.ParseExportFormat(format)
 			if err != nil {
-				log.Fatalf("error exporting notes: %v", err)
+				return usageError{err}
 			}
-			return nil
+
+			// - streams the zip to stdout
+			if out == "-" {
+				if jsonOutput {
+					return usageError{errors.New("--json can't be used when the zip goes to stdout")}
+				}
+				return s.ExportAll(os.Stdout, exportFormat)
+			}
+
+			if out == "" {
+				dir, err := local.DefaultExportDir()
+				if err != nil {
+					return err
+				}
+				out = dir
+			}
+			// A directory gets the zip inside it
+			if info, err := os.Stat(out); err == nil && info.IsDir() {
+				out = filepath.Join(out, "biji-export.zip")
+			}
+
+			if err := s.ExportAllTo(out, exportFormat); err != nil {
+				return err
+			}
+
+			return done(map[string]string{"file": out, "format": string(exportFormat)}, "Export complete, saved to %s\n", out)
 		},
 	}
 
+	cmd.Flags().StringVar(&format, "format", "md", "file format: md, html, print (printable html), json or txt")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "zip file or directory to write to, - for stdout (default ~/Documents/biji-export.zip)")
+
 	return &cmd
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew_ContentSources(t *testing.T) {
+	store := newTestStore(t)
+
+	file := filepath.Join(t.TempDir(), "meeting_notes.md")
+	if err := os.WriteFile(file, []byte("\nfrom the file\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		stdin   string
+		args    []string
+		path    string
+		content string
+	}{
+		{"", []string{"new", "inline", `one\ntwo`}, "inline", "one\ntwo"},
+		{"from stdin\n", []string{"new", "standup"}, "standup", "from stdin"},
+		{"ignored\n", []string{"new", "args win", "from args"}, "args win", "from args"},
+		{"", []string{"new", "--file", file}, "meeting_notes", "from the file"},
+		{"", []string{"new", "-f", file, "renamed"}, "renamed", "from the file"},
+		{"more from stdin", []string{"new", "standup", "--append"}, "standup", "from stdin\nmore from stdin"},
+		{"", []string{"new", "--append", "-f", file, "standup"}, "standup", "from stdin\nmore from stdin\nfrom the file"},
+		{"first line", []string{"new", "-n", "work", "log", "-a"}, "work/log", "first line"},
+		{"second line", []string{"new", "-n", "work", "log", "-a"}, "work/log", "first line\nsecond line"},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runCmd(t, store, tt.stdin, tt.args...)
+		if code != 0 {
+			t.Errorf("%v: expected success, got exit code %d: %s", tt.args, code, stderr)
+			continue
+		}
+		id, err := store.FindNoteID(tt.path)
+		if err != nil {
+			t.Errorf("%v: expected a note at %s: %v", tt.args, tt.path, err)
+			continue
+		}
+		if note, _ := store.GetNoteFromID(id); note.Content != tt.content {
+			t.Errorf("%v: expected %q, got %q", tt.args, tt.content, note.Content)
+		}
+	}
+
+	if n := len(store.Notes); n != 6 {
+		t.Errorf("Expected appending to reuse notes, got %d notes", n)
+	}
+
+	for _, tt := range []struct {
+		stdin string
+		args  []string
+		code  int
+	}{
+		{"no name", []string{"new"}, exitUsage},
+		{"", []string{"new", "--file", filepath.Join(t.TempDir(), "missing.md")}, exitIO},
+		{"taken", []string{"new", "standup"}, exitConflict},
+		{"", []string{"new", "a/b", "slash"}, exitUsage},
+	} {
+		if code, _, stderr := runCmd(t, store, tt.stdin, tt.args...); code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d: %s", tt.args, tt.code, code, stderr)
+		}
+	}
+}
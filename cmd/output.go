@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/sync"
+	"github.com/spf13/cobra"
+)
+
+// Set by the global --json and --quiet flags.
+var (
+	jsonOutput bool
+	quiet      bool
+)
+
+// Exit codes, so scripts can tell why a command failed.
+const (
+	exitFailure  = 1 // anything not below
+	exitUsage    = 2 // bad arguments or flags, or a name that matches several notes
+	exitNotFound = 3 // no such note, notebook, version, conflict or sync link, or only a fuzzy match
+	exitConflict = 4 // the name is taken, or another process changed the note first
+	exitIO       = 5 // reading or writing a file, or reaching the sync server, failed
+)
+
+// usageError is an error in how a command was called rather than in what it did.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// exitCode returns the exit code for err and the name --json gives it.
+func exitCode(err error) (int, string) {
+	var usage usageError
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var netErr net.Error
+	var serverErr *sync.ServerError
+	var ambiguous *local.AmbiguousError
+
+	switch {
+	case errors.As(err, &usage):
+		return exitUsage, "usage"
+	case errors.As(err, &ambiguous) && !ambiguous.Fuzzy:
+		return exitUsage, "ambiguous"
+	case errors.Is(err, local.ErrNotFound), errors.Is(err, sync.ErrNotFound), errors.Is(err, sync.ErrNotLinked):
+		return exitNotFound, "not_found"
+	case errors.Is(err, local.ErrConflict), errors.Is(err, local.ErrNameTaken):
+		return exitConflict, "conflict"
+	case errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &netErr):
+		return exitIO, "io"
+	case errors.As(err, &serverErr) && serverErr.StatusCode >= 500:
+		return exitIO, "io"
+	default:
+		return exitFailure, "error"
+	}
+}
+
+type errorOutput struct {
+	Error struct {
+		Code    string `json:"code"`
+		Exit    int    `json:"exit"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// reportError prints err on stderr, as JSON with --json, and returns the exit code for it.
+func reportError(cmd *cobra.Command, err error) int {
+	code, name := exitCode(err)
+
+	if jsonOutput {
+		var out errorOutput
+		out.Error.Code, out.Error.Exit, out.Error.Message = name, code, err.Error()
+
+		enc := json.NewEncoder(os.Stderr)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+		return code
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if code == exitUsage && cmd != nil {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return code
+}
+
+// usageArgs wraps the args check of cmd and every command under it so a wrong
+// number of args exits with exitUsage.
+func usageArgs(cmd *cobra.Command) {
+	if check := cmd.Args; check != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := check(cmd, args); err != nil {
+				return usageError{err}
+			}
+			return nil
+		}
+	}
+
+	for _, sub := range cmd.Commands() {
+		usageArgs(sub)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("error writing json: %w", err)
+	}
+
+	return nil
+}
+
+// done reports what a command did, result as JSON with --json and otherwise the
+// message, unless --quiet.
+func done(result any, format string, args ...any) error {
+	if jsonOutput {
+		return printJSON(result)
+	}
+
+	say(format, args...)
+	return nil
+}
+
+// say prints a message for people, nothing with --json or --quiet.
+func say(format string, args ...any) {
+	if jsonOutput || quiet {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// prompt asks for a line of input. The question goes to stderr so it doesn't end up
+// in output a script reads.
+func prompt(question string) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+
+	rawInput, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+
+	return strings.TrimSpace(rawInput), nil
+}
+
+// noteEntry is how --json shows a note, everything but the content and its history.
+type noteEntry struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Notebook string    `json:"notebook,omitempty"`
+	Path     string    `json:"path"`
+	Tags     []string  `json:"tags,omitempty"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Deleted  time.Time `json:"deleted,omitzero"`
+	Version  int       `json:"version"`
+	Words    int       `json:"words"`
+	Size     int       `json:"size"` // bytes of content
+	Pinned   bool      `json:"pinned,omitempty"`
+	Favorite bool      `json:"favorite,omitempty"`
+}
+
+func newNoteEntry(note local.Note) noteEntry {
+	return noteEntry{
+		ID:       note.ID,
+		Name:     note.Name,
+		Notebook: note.Notebook,
+		Path:     note.Path(),
+		Tags:     note.AllTags(),
+		Created:  note.CreatedAt,
+		Modified: note.ModifiedAt,
+		Deleted:  note.DeletedAt,
+		Version:  note.Version,
+		Words:    note.Words(),
+		Size:     len(note.Content),
+		Pinned:   note.Pinned,
+		Favorite: note.Favorite,
+	}
+}
+
+func noteEntries(notes []local.Note) []noteEntry {
+	entries := make([]noteEntry, 0, len(notes))
+	for _, note := range notes {
+		entries = append(entries, newNoteEntry(note))
+	}
+	return entries
+}
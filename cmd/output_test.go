@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/sync"
+)
+
+// newTestStore opens a store in a temp dir for the commands to run against.
+func newTestStore(t *testing.T) *local.Store {
+	store := &local.Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// runCmd runs biji with args against store, input piped to stdin, and returns the exit
+// code and what it printed on stdout and stderr. With no input stdin isn't a pipe, like
+// when biji runs in a terminal.
+func runCmd(t *testing.T, store *local.Store, input string, args ...string) (int, string, string) {
+	t.Helper()
+
+	in := os.DevNull
+	if input != "" {
+		in = filepath.Join(t.TempDir(), "stdin")
+		if err := os.WriteFile(in, []byte(input), 0o644); err != nil {
+			t.Fatalf("Failed to write stdin: %v", err)
+		}
+	}
+	stdinFile, err := os.Open(in)
+	if err != nil {
+		t.Fatalf("Failed to open stdin: %v", err)
+	}
+	defer stdinFile.Close()
+
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stderrR, stderrW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	outc, errc := make(chan []byte), make(chan []byte)
+	go func() { b, _ := io.ReadAll(stdoutR); outc <- b }()
+	go func() { b, _ := io.ReadAll(stderrR); errc <- b }()
+
+	stdin, stdout, stderr, open := os.Stdin, os.Stdout, os.Stderr, openStore
+	os.Stdin, os.Stdout, os.Stderr = stdinFile, stdoutW, stderrW
+	openStore = func(*local.Store) error { return nil }
+	defer func() {
+		os.Stdin, os.Stdout, os.Stderr, openStore = stdin, stdout, stderr, open
+		jsonOutput, quiet = false, false
+	}()
+
+	// Execute adds the commands again, bound to this store and with their flags unset
+	rootCmd.ResetCommands()
+	rootCmd.SetArgs(args)
+	code := Execute(store)
+
+	stdoutW.Close()
+	stderrW.Close()
+	return code, string(<-outc), string(<-errc)
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+		name string
+	}{
+		{errors.New("boom"), exitFailure, "error"},
+		{usageError{errors.New("bad flag")}, exitUsage, "usage"},
+		{&local.AmbiguousError{Query: "todo", Matches: []local.Note{{Name: "todo"}, {Name: "todo"}}}, exitUsage, "ambiguous"},
+		{&local.AmbiguousError{Query: "mtg", Matches: []local.Note{{Name: "meeting"}}, Fuzzy: true}, exitNotFound, "not_found"},
+		{fmt.Errorf("failed to get note: %w", local.ErrNotFound), exitNotFound, "not_found"},
+		{sync.ErrNotFound, exitNotFound, "not_found"},
+		{sync.ErrNotLinked, exitNotFound, "not_found"},
+		{fmt.Errorf("failed to rename note: %w", local.ErrNameTaken), exitConflict, "conflict"},
+		{local.ErrConflict, exitConflict, "conflict"},
+		{&fs.PathError{Op: "open", Path: "x", Err: fs.ErrPermission}, exitIO, "io"},
+		{&os.LinkError{Op: "rename", Old: "a", New: "b", Err: fs.ErrExist}, exitIO, "io"},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, exitIO, "io"},
+		{&sync.ServerError{StatusCode: 503}, exitIO, "io"},
+		{&sync.ServerError{StatusCode: 400}, exitFailure, "error"},
+	}
+
+	for _, tt := range tests {
+		if code, name := exitCode(tt.err); code != tt.code || name != tt.name {
+			t.Errorf("exitCode(%v) = %d, %s, want %d, %s", tt.err, code, name, tt.code, tt.name)
+		}
+	}
+}
+
+func TestExecute_ExitCodes(t *testing.T) {
+	store := newTestStore(t)
+	for _, note := range [][2]string{{"", "taken"}, {"", "other"}, {"a", "dup"}, {"b", "dup"}} {
+		if _, err := store.AddNoteTo(note[0], note[1], ""); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		code int
+		name string
+	}{
+		{[]string{"view", "taken"}, 0, ""},
+		{[]string{"view"}, exitUsage, "usage"},
+		{[]string{"view", "taken", "--nope"}, exitUsage, "usage"},
+		{[]string{"view", "missing"}, exitNotFound, "not_found"},
+		{[]string{"view", "dup"}, exitUsage, "ambiguous"},
+		{[]string{"rename", "other", "taken"}, exitConflict, "conflict"},
+	}
+
+	for _, tt := range tests {
+		code, _, stderr := runCmd(t, store, "", append([]string{"--json"}, tt.args...)...)
+		if code != tt.code {
+			t.Errorf("%v: expected exit code %d, got %d: %s", tt.args, tt.code, code, stderr)
+			continue
+		}
+		if code == 0 {
+			continue
+		}
+
+		var out errorOutput
+		if err := json.Unmarshal([]byte(stderr), &out); err != nil {
+			t.Errorf("%v: expected a json error, got %q: %v", tt.args, stderr, err)
+			continue
+		}
+		if out.Error.Code != tt.name || out.Error.Exit != tt.code || out.Error.Message == "" {
+			t.Errorf("%v: expected a %s error with exit %d, got %+v", tt.args, tt.name, tt.code, out.Error)
+		}
+	}
+}
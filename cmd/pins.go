@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func pin(s *local.Store) *cobra.Command {
+	var position int
+	var favorite bool
+
+	cmd := cobra.Command{
+		Use:   "pin [name] [name] ...",
+		Short: "Pin notes so they're listed first, or star them with --favorite",
+		Long: `Pin notes so they're listed first, in the order they were pinned.
+Pinning a pinned note with --at moves it, --at 1 puts it at the top.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var changed []local.Note
+			for i, name := range args {
+				id, err := resolveNote(s.Notes, name)
+				if err != nil {
+					return err
+				}
+
+				if favorite {
+					note, err := s.SetFavorite(id, true)
+					if err != nil {
+						return fmt.Errorf("failed to favorite note: %w", err)
+					}
+					say("%s added to favorites\n", name)
+					changed = append(changed, note)
+					continue
+				}
+
+				// Notes pinned together stay in the order they were given
+				at := position
+				if at > 0 {
+					at += i
+				}
+				note, err := s.PinNote(id, at)
+				if err != nil {
+					return fmt.Errorf("failed to pin note: %w", err)
+				}
+				say("%s pinned at %d\n", name, note.Position)
+				changed = append(changed, note)
+			}
+
+			if jsonOutput {
+				return printJSON(noteEntries(changed))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&position, "at", 0, "place among the pinned notes, from 1 (default last)")
+	cmd.Flags().BoolVar(&favorite, "favorite", false, "mark as a favorite instead of pinning")
+
+	return &cmd
+}
+
+func unpin(s *local.Store) *cobra.Command {
+	var favorite bool
+
+	cmd := cobra.Command{
+		Use:   "unpin [name] [name] ...",
+		Short: "Unpin notes, or take them out of favorites with --favorite",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var changed []local.Note
+			for _, name := range args {
+				id, err := resolveNote(s.Notes, name)
+				if err != nil {
+					return err
+				}
+
+				if favorite {
+					note, err := s.SetFavorite(id, false)
+					if err != nil {
+						return fmt.Errorf("failed to unfavorite note: %w", err)
+					}
+					say("%s removed from favorites\n", name)
+					changed = append(changed, note)
+					continue
+				}
+
+				note, err := s.UnpinNote(id)
+				if err != nil {
+					return fmt.Errorf("failed to unpin note: %w", err)
+				}
+				say("%s unpinned\n", name)
+				changed = append(changed, note)
+			}
+
+			if jsonOutput {
+				return printJSON(noteEntries(changed))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&favorite, "favorite", false, "remove from favorites instead of unpinning")
+
+	return &cmd
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+)
+
+// maxPicked is how many notes the picker offers, the best matches come first.
+const maxPicked = 20
+
+// resolveNote returns the ID of the note among notes that query means, by name, path
+// or ID prefix, see local.ResolveNote. When it could be several notes, or only a fuzzy
+// match comes close, the user picks one if biji runs in a terminal. Otherwise that's an
+// error naming the candidates, so a typo in a script never picks a note on its own.
+func resolveNote(notes []local.Note, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", usageError{errors.New("note name is empty")}
+	}
+
+	note, err := local.ResolveNote(notes, query)
+
+	var ambiguous *local.AmbiguousError
+	if errors.As(err, &ambiguous) && interactive() {
+		return pickNote(ambiguous)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Say which note a loose match found, before anything happens to it
+	if note.Path() != query && note.Name != query && note.ID != query && !quiet && !jsonOutput {
+		fmt.Fprintf(os.Stderr, "Using %s\n", note.Path())
+	}
+
+	return note.ID, nil
+}
+
+// pickNote asks which of the notes a query matched was meant, or whether the one
+// note a fuzzy match found was.
+func pickNote(ambiguous *local.AmbiguousError) (string, error) {
+	matches := ambiguous.Matches[:min(len(ambiguous.Matches), maxPicked)]
+
+	if len(matches) == 1 {
+		answer, err := prompt(fmt.Sprintf("No note is called %s, did you mean %s? [y/N] ", ambiguous.Query, matches[0].Path()))
+		if err != nil {
+			return "", err
+		}
+		if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+			return "", ambiguous
+		}
+		return matches[0].ID, nil
+	}
+
+	if ambiguous.Fuzzy {
+		fmt.Fprintf(os.Stderr, "No note is called %s, did you mean:\n", ambiguous.Query)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s could be more than one note:\n", ambiguous.Query)
+	}
+	for i, note := range matches {
+		fmt.Fprintf(os.Stderr, "	%d) %s\n", i+1, note.Path())
+	}
+
+	answer, err := prompt(fmt.Sprintf("Which one? [1-%d] ", len(matches)))
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(matches) {
+		return "", ambiguous
+	}
+
+	return matches[n-1].ID, nil
+}
+
+// interactive reports whether the user can be asked things, stdin and stderr
+// both being a terminal.
+func interactive() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stderr} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
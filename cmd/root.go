@@ -3,8 +3,7 @@
 package cmd
 
 import (
-	"log"
-	"os"
+	"fmt"
 
 	"github.com/dallas1295/biji/local"
 	"github.com/dallas1295/biji/tui"
@@ -16,34 +15,85 @@ var rootCmd = &cobra.Command{
 	Use:   "biji",
 	Short: "biji is a note taking application designed for both TUI and GUI environments.",
 	Long: `biji is a not taking application designed for both TUI and GUI environments.
-		It's being used to learn Go lang for both scripting and normal app development;`,
+		It's being used to learn Go lang for both scripting and normal app development;
+
+Exit codes: 0 success, 1 any other error, 2 bad arguments, flags or an ambiguous
+name, 3 not found, 4 conflict (name taken or changed by another process), 5 I/O or
+network error.
+
+Notes can be named by path, name or the start of their ID. When that could be several
+notes, or a name only comes close like mtg for meeting, biji asks which in a terminal
+and otherwise fails, naming the notes it could be.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("unknown command %q for %q", args[0], cmd.CommandPath())
+		}
+		return nil
+	},
 }
 
+// openStore opens the store before any command runs. Tests open their own.
+var openStore = (*local.Store).Init
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-// It takes local.Store as a param for use by commands
-func Execute(s *local.Store) {
+// It takes local.Store as a param for use by commands, opens it, and returns the exit code.
+func Execute(s *local.Store) int {
 	rootCmd.AddCommand(newNote(s))
+	rootCmd.AddCommand(editNote(s))
 	rootCmd.AddCommand(deleteNote(s))
 	rootCmd.AddCommand(updateNoteName(s))
 	rootCmd.AddCommand(listNotes(s))
 	rootCmd.AddCommand(viewNote(s))
+	rootCmd.AddCommand(search(s))
+	rootCmd.AddCommand(tag(s))
+	rootCmd.AddCommand(pin(s))
+	rootCmd.AddCommand(unpin(s))
+	rootCmd.AddCommand(notebook(s))
+	rootCmd.AddCommand(links(s))
+	rootCmd.AddCommand(backlinks(s))
 	rootCmd.AddCommand(export(s))
 	rootCmd.AddCommand(migrate(s))
+	rootCmd.AddCommand(importNotes(s))
+	rootCmd.AddCommand(history(s))
+	rootCmd.AddCommand(revert(s))
+	rootCmd.AddCommand(trash(s))
+	rootCmd.AddCommand(conflicts(s))
+	rootCmd.AddCommand(syncCmd(s))
+
+	// The store is opened once the flags are parsed, so failing to open it is reported
+	// like any other error, as JSON with --json
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := openStore(s); err != nil {
+			return fmt.Errorf("failed to initialize store: %w", err)
+		}
+		return nil
+	}
 
 	// Defining absent subcommands to launch the tui environment.
 
-	rootCmd.Run = func(cmd *cobra.Command, args []string) {
+	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		// initialize tui
 		if err := tui.Run(s); err != nil {
-			log.Fatalf("TUI exited with err: %v", err)
+			return fmt.Errorf("TUI exited with err: %w", err)
 		}
+		return nil
 	}
 
-	err := rootCmd.Execute()
+	// Errors are printed by reportError, with an exit code for their kind
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError{err}
+	})
+	usageArgs(rootCmd)
+
+	cmd, err := rootCmd.ExecuteC()
 	if err != nil {
-		os.Exit(1)
+		return reportError(cmd, err)
 	}
+
+	return 0
 }
 
 func init() {
@@ -52,6 +102,8 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.biji.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print results and errors as JSON")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print what was asked for, like a list or a note, and errors")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
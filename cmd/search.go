@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func search(s *local.Store) *cobra.Command {
+	var exclude []string
+
+	cmd := cobra.Command{
+		Use:   "search [query]",
+		Short: "Search note names and content",
+		Long: `Search note names and content. Every word has to appear, "quoted phrases" have to
+appear in that order and word* matches as a prefix. --not leaves out notes that contain
+a word or phrase, so does -word after a -- like biji search -- cat -dog.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The shell already took the quotes off phrases, put them back
+			terms := make([]string, 0, len(args)+len(exclude))
+			for _, arg := range args {
+				terms = append(terms, searchTerm(arg))
+			}
+			for _, word := range exclude {
+				terms = append(terms, "-"+strings.TrimPrefix(searchTerm(word), "-"))
+			}
+			query := strings.Join(terms, " ")
+
+			results, err := s.Search(query)
+			if err != nil {
+				return usageError{fmt.Errorf("search failed: %w", err)}
+			}
+
+			if jsonOutput {
+				type match struct {
+					noteEntry
+					Score   float64 `json:"score"`
+					Snippet string  `json:"snippet,omitempty"`
+				}
+				matches := make([]match, 0, len(results))
+				for _, result := range results {
+					matches = append(matches, match{newNoteEntry(result.Note), result.Score, result.Snippet})
+				}
+				return printJSON(matches)
+			}
+
+			if len(results) == 0 {
+				fmt.Printf("	no matches\n")
+				return nil
+			}
+
+			fmt.Printf("Matches for %s:\n", query)
+			for _, result := range results {
+				fmt.Printf("	%s\n", result.Note.Path())
+				if result.Snippet != "" {
+					fmt.Printf("		%s\n", result.Snippet)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&exclude, "not", nil, "Leave out notes that contain this word or phrase, can be repeated")
+
+	return &cmd
+}
+
+// searchTerm quotes arg when it's a phrase, keeping a leading - outside the quotes.
+func searchTerm(arg string) string {
+	if !strings.ContainsAny(arg, " \t") || strings.Contains(arg, `"`) {
+		return arg
+	}
+
+	if phrase, ok := strings.CutPrefix(arg, "-"); ok {
+		return `-"` + phrase + `"`
+	}
+	return `"` + arg + `"`
+}
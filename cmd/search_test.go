@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSearch_JSONFlag(t *testing.T) {
+	store := newTestStore(t)
+	if _, err := store.AddNote("greeting", "hello world"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.AddNote("farewell", "goodbye world"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	code, out, stderr := runCmd(t, store, "", "search", "--json", "world", "--not", "goodbye")
+	if code != 0 {
+		t.Fatalf("Expected search to succeed, got exit code %d and %s", code, stderr)
+	}
+
+	var matches []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(out), &matches); err != nil {
+		t.Fatalf("Expected json, got %q: %v", out, err)
+	}
+	if len(matches) != 1 || matches[0].Name != "greeting" {
+		t.Errorf("Expected only greeting to match, got %+v", matches)
+	}
+}
+
+func TestSearch_PrintsPaths(t *testing.T) {
+	store := newTestStore(t)
+	for _, notebook := range []string{"work", "home"} {
+		if _, err := store.AddNoteTo(notebook, "todo", "buy milk"); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+	}
+
+	code, out, stderr := runCmd(t, store, "", "search", "milk")
+	if code != 0 {
+		t.Fatalf("Expected search to succeed, got exit code %d and %s", code, stderr)
+	}
+	if !strings.Contains(out, "\twork/todo\n") || !strings.Contains(out, "\thome/todo\n") {
+		t.Errorf("Expected both notes by their path, got %q", out)
+	}
+}
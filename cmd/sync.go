@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/sync"
+	"github.com/spf13/cobra"
+)
+
+func syncCmd(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "sync",
+		Short: "Keep notes in step with a biji-server",
+	}
+
+	cmd.AddCommand(syncRegister(s))
+	cmd.AddCommand(syncLink(s))
+	cmd.AddCommand(syncNow(s))
+	cmd.AddCommand(syncStatus(s))
+	cmd.AddCommand(syncUnlink(s))
+
+	return &cmd
+}
+
+func syncRegister(s *local.Store) *cobra.Command {
+	var serverURL, strategy string
+
+	cmd := cobra.Command{
+		Use:   "register",
+		Short: "Create a new sync code on a server and link this device to it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureUnlinked(s); err != nil {
+				return err
+			}
+
+			config := &sync.Config{Server: serverURL, Strategy: strategy}
+			client, err := config.Client()
+			if err != nil {
+				return usageError{fmt.Errorf("invalid sync settings: %w", err)}
+			}
+
+			config.SyncCode, err = client.Register(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to register: %w", err)
+			}
+
+			if err := config.Save(s.Dir()); err != nil {
+				return fmt.Errorf("failed to save sync config: %w", err)
+			}
+
+			// The code is the point of registering, so --quiet still prints it
+			if jsonOutput {
+				return printJSON(config)
+			}
+			if quiet {
+				fmt.Println(config.SyncCode)
+				return nil
+			}
+			fmt.Printf("Registered with %s\nSync code: %s\n", serverURL, config.SyncCode)
+			fmt.Println("Use this code with biji sync link on your other devices")
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&serverURL, "server", "", "URL of the biji-server")
+	cmd.Flags().StringVar(&strategy, "strategy", "", "conflict strategy: last-writer-wins, keep-both or merge")
+	cmd.MarkFlagRequired("server")
+
+	return &cmd
+}
+
+func syncLink(s *local.Store) *cobra.Command {
+	var serverURL, strategy string
+
+	cmd := cobra.Command{
+		Use:   "link [code]",
+		Short: "Link this device to an existing sync code",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ensureUnlinked(s); err != nil {
+				return err
+			}
+
+			// Sync codes are printed in groups, so they may arrive as several args
+			code := strings.ToUpper(strings.Join(strings.Fields(strings.Join(args, " ")), " "))
+
+			config := &sync.Config{Server: serverURL, SyncCode: code, Strategy: strategy}
+			client, err := config.Client()
+			if err != nil {
+				return usageError{fmt.Errorf("invalid sync settings: %w", err)}
+			}
+
+			// Check the code exists before saving it
+			if _, err := client.Pull(cmd.Context()); err != nil {
+				return fmt.Errorf("failed to link: %w", err)
+			}
+
+			if err := config.Save(s.Dir()); err != nil {
+				return fmt.Errorf("failed to save sync config: %w", err)
+			}
+
+			return done(config, "Linked to %s, run biji sync now to pull your notes\n", serverURL)
+		},
+	}
+
+	cmd.Flags().StringVar(&serverURL, "server", "", "URL of the biji-server")
+	cmd.Flags().StringVar(&strategy, "strategy", "", "conflict strategy: last-writer-wins, keep-both or merge")
+	cmd.MarkFlagRequired("server")
+
+	return &cmd
+}
+
+func syncNow(s *local.Store) *cobra.Command {
+	var strategy string
+
+	cmd := cobra.Command{
+		Use:   "now",
+		Short: "Push local changes and pull changes from other devices",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sync.LoadConfig(s.Dir())
+			if err != nil {
+				return err
+			}
+			if strategy != "" {
+				config.Strategy = strategy
+			}
+
+			client, err := config.Client()
+			if err != nil {
+				return usageError{fmt.Errorf("invalid sync settings: %w", err)}
+			}
+
+			res, err := client.Sync(cmd.Context(), s)
+			if err != nil {
+				return fmt.Errorf("sync failed: %w", err)
+			}
+
+			if jsonOutput {
+				conflicts := res.Conflicts
+				if conflicts == nil {
+					conflicts = []sync.Conflict{}
+				}
+				return printJSON(map[string]any{
+					"pushed":    res.Pushed,
+					"pulled":    res.Pulled,
+					"resolved":  res.Resolved,
+					"conflicts": conflicts,
+					"syncTime":  res.SyncTime,
+				})
+			}
+
+			say("Synced: %d pushed, %d pulled", res.Pushed, res.Pulled)
+			if res.Resolved > 0 {
+				say(", %d resolved", res.Resolved)
+			}
+			say("\n")
+
+			// Conflicts need the user, so they're reported even with --quiet
+			if len(res.Conflicts) > 0 {
+				fmt.Fprintf(os.Stderr, "%d conflict(s) need attention, see biji conflicts\n", len(res.Conflicts))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&strategy, "strategy", "", "conflict strategy for this sync only")
+
+	return &cmd
+}
+
+func syncStatus(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "status",
+		Short: "Show the sync server, last sync and pending changes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sync.LoadConfig(s.Dir())
+			if errors.Is(err, sync.ErrNotLinked) {
+				return done(map[string]bool{"linked": false}, "Not linked to a sync server\n")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load sync config: %w", err)
+			}
+
+			lastSync := "never"
+			if last := s.LastSynced(); !last.IsZero() {
+				lastSync = last.Local().Format("2006-01-02 15:04:05")
+			}
+
+			strategy := config.Strategy
+			if strategy == "" {
+				strategy = "last-writer-wins"
+			}
+
+			found, err := sync.LoadConflicts(s.Dir())
+			if err != nil {
+				return fmt.Errorf("failed to load conflicts: %w", err)
+			}
+
+			if jsonOutput {
+				status := map[string]any{
+					"linked":    true,
+					"server":    config.Server,
+					"syncCode":  config.SyncCode,
+					"strategy":  strategy,
+					"pending":   len(s.PendingSync()),
+					"conflicts": len(found),
+				}
+				if last := s.LastSynced(); !last.IsZero() {
+					status["lastSync"] = last
+				}
+				return printJSON(status)
+			}
+
+			fmt.Printf("Server:    %s\n", config.Server)
+			fmt.Printf("Sync code: %s\n", config.SyncCode)
+			fmt.Printf("Strategy:  %s\n", strategy)
+			fmt.Printf("Last sync: %s\n", lastSync)
+			fmt.Printf("Pending:   %d note(s)\n", len(s.PendingSync()))
+			fmt.Printf("Conflicts: %d\n", len(found))
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func syncUnlink(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "unlink",
+		Short: "Stop syncing this device, local notes are kept",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := sync.LoadConfig(s.Dir())
+			if err != nil {
+				return err
+			}
+
+			if err := sync.Unlink(s); err != nil {
+				return fmt.Errorf("failed to unlink: %w", err)
+			}
+
+			return done(config, "Unlinked from sync server\n")
+		},
+	}
+
+	return &cmd
+}
+
+func ensureUnlinked(s *local.Store) error {
+	config, err := sync.LoadConfig(s.Dir())
+	if err == nil {
+		return fmt.Errorf("already linked to %s, run biji sync unlink first", config.Server)
+	}
+	if !errors.Is(err, sync.ErrNotLinked) {
+		return fmt.Errorf("failed to load sync config: %w", err)
+	}
+
+	return nil
+}
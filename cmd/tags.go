@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func tag(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "tag",
+		Short: "Add, remove and list note tags",
+	}
+
+	cmd.AddCommand(addTags(s))
+	cmd.AddCommand(removeTags(s))
+	cmd.AddCommand(listTags(s))
+
+	return &cmd
+}
+
+func addTags(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "add [name] [tag] [tag] ...",
+		Short: "Tag a note",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := resolveNote(s.Notes, args[0])
+			if err != nil {
+				return err
+			}
+
+			note, err := s.AddTags(id, args[1:]...)
+			if err != nil {
+				return fmt.Errorf("failed to tag note: %w", err)
+			}
+
+			return done(newNoteEntry(note), "%s tagged %s\n", note.Name, formatTags(note.AllTags()))
+		},
+	}
+
+	return &cmd
+}
+
+func removeTags(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "rm [name] [tag] [tag] ...",
+		Short: "Take tags off a note",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := resolveNote(s.Notes, args[0])
+			if err != nil {
+				return err
+			}
+
+			note, err := s.RemoveTags(id, args[1:]...)
+			if err != nil {
+				return fmt.Errorf("failed to untag note: %w", err)
+			}
+
+			for _, t := range args[1:] {
+				if note.HasTag(t) {
+					say("#%s is still written in the content of %s\n", local.NormalizeTag(t), note.Name)
+				}
+			}
+
+			return done(newNoteEntry(note), "%s tagged %s\n", note.Name, formatTags(note.AllTags()))
+		},
+	}
+
+	return &cmd
+}
+
+func listTags(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "ls [name]",
+		Short: "List every tag in use, or the tags of one note",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				id, err := resolveNote(s.Notes, args[0])
+				if err != nil {
+					return err
+				}
+
+				note, err := s.GetNoteFromID(id)
+				if err != nil {
+					return err
+				}
+
+				if jsonOutput {
+					return printJSON(newNoteEntry(note))
+				}
+				fmt.Printf("%s tagged %s\n", note.Name, formatTags(note.AllTags()))
+				return nil
+			}
+
+			tags := s.Tags()
+			if jsonOutput {
+				type tagEntry struct {
+					Tag   string `json:"tag"`
+					Count int    `json:"count"`
+				}
+				entries := make([]tagEntry, 0, len(tags))
+				for _, t := range tags {
+					entries = append(entries, tagEntry{t.Tag, t.Count})
+				}
+				return printJSON(entries)
+			}
+			if len(tags) == 0 {
+				fmt.Printf("	no tags\n")
+				return nil
+			}
+
+			fmt.Println("Tags:")
+			for _, t := range tags {
+				fmt.Printf("	#%s (%d)\n", t.Tag, t.Count)
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "nothing"
+	}
+	return "#" + strings.Join(tags, " #")
+}
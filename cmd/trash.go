@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/spf13/cobra"
+)
+
+func trash(s *local.Store) *cobra.Command {
+	list := listTrash(s)
+
+	cmd := cobra.Command{
+		Use:   "trash",
+		Short: "List, restore or empty deleted notes, trash on its own lists them",
+		Args:  cobra.NoArgs,
+		RunE:  list.RunE,
+	}
+
+	cmd.AddCommand(list)
+	cmd.AddCommand(restoreNote(s))
+	cmd.AddCommand(emptyTrash(s))
+
+	return &cmd
+}
+
+func listTrash(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "list",
+		Short: "List deleted notes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if jsonOutput {
+				return printJSON(noteEntries(s.Trash))
+			}
+			if len(s.Trash) == 0 {
+				fmt.Println("Trash is empty")
+				return nil
+			}
+
+			fmt.Println("Trash:")
+			for _, note := range s.Trash {
+				fmt.Printf("	%s (deleted %s)\n", note.Name, note.DeletedAt.Local().Format("2006-01-02 15:04"))
+			}
+
+			return nil
+		},
+	}
+
+	return &cmd
+}
+
+func restoreNote(s *local.Store) *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "restore [name]",
+		Short: "Move a note out of the trash",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := strings.TrimSpace(args[0])
+
+			id, err := resolveNote(s.Trash, name)
+			if err != nil {
+				return fmt.Errorf("not in the trash: %w", err)
+			}
+
+			note, err := s.RestoreNote(id)
+			if err != nil {
+				return fmt.Errorf("failed to restore note: %w", err)
+			}
+
+			return done(newNoteEntry(note), "%s restored\n", note.Name)
+		},
+	}
+
+	return &cmd
+}
+
+func emptyTrash(s *local.Store) *cobra.Command {
+	var force bool
+
+	cmd := cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete every note in the trash",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(s.Trash) == 0 {
+				return done(map[string]int{"deleted": 0}, "Trash is empty\n")
+			}
+
+			if !force {
+				answer, err := prompt(fmt.Sprintf("Permanently delete %d notes? [y/N] ", len(s.Trash)))
+				if err != nil {
+					return err
+				}
+				if answer = strings.ToLower(answer); answer != "y" && answer != "yes" {
+					return done(map[string]int{"deleted": 0}, "Trash left as is\n")
+				}
+			}
+
+			n, err := s.EmptyTrash()
+			if err != nil {
+				return fmt.Errorf("failed to empty trash: %w", err)
+			}
+
+			// Deletions other devices haven't heard of yet have to sync first, or they'd come back
+			if left := len(s.Trash); left > 0 {
+				return done(map[string]int{"deleted": n, "pending": left},
+					"%d notes deleted for good, %d more go once their deletion syncs\n", n, left)
+			}
+			return done(map[string]int{"deleted": n}, "%d notes deleted for good\n", n)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "skip the confirmation")
+
+	return &cmd
+}
@@ -0,0 +1,252 @@
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// backupCount is how many previous versions of biji.json are kept as biji.json.bak.N,
+// .bak.1 being the newest.
+const backupCount = 3
+
+// Backend persists the notes of a Store. The Store keeps every note in memory
+// and only tells the backend what changed, so a backend is free to write
+// single rows instead of the whole notebook.
+type Backend interface {
+	// Load reads every stored note.
+	Load() ([]Note, error)
+	// Put inserts the notes or replaces the stored ones with the same ID.
+	Put(notes ...Note) error
+	// Remove deletes the notes with the ids, missing notes are not an error.
+	Remove(ids ...string) error
+	// Lookup finds the stored note with the id, trashed or not.
+	Lookup(id string) (Note, bool, error)
+	// LookupName finds the stored notes called name in any notebook, trashed or not.
+	LookupName(name string) ([]Note, error)
+	// Changed reports whether the stored notes could differ from what the backend last
+	// loaded or wrote, another process having changed them. When they can't, the Store
+	// doesn't need to reload them before a write.
+	Changed() (bool, error)
+	// Close releases whatever the backend holds open.
+	Close() error
+}
+
+// jsonBackend keeps all notes in a single JSON file that is rewritten on every change.
+// Writes are atomic and the previous versions are kept as rolling backups, which Load
+// falls back to when the file is damaged.
+type jsonBackend struct {
+	path   string
+	notes  []Note
+	loaded bool
+	seen   os.FileInfo // the file as of the last Load or save, see Changed
+}
+
+func openJSONBackend(path string) (*jsonBackend, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err = WriteFileAtomic(path, []byte("[]"), 0o644); err != nil {
+			return nil, fmt.Errorf("error creating biji.json: %w", err)
+		}
+	}
+
+	return &jsonBackend{path: path}, nil
+}
+
+func (b *jsonBackend) Load() ([]Note, error) {
+	notes, err := readNotesFile(b.path)
+	if err != nil {
+		notes, err = b.recover(err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b.notes = make([]Note, len(notes))
+	copy(b.notes, notes)
+	b.loaded = true
+	b.seen, _ = os.Stat(b.path)
+
+	return notes, nil
+}
+
+// Changed compares the file with the one last loaded or saved. Every save replaces the
+// file, so another process having saved means another file, not just a newer time.
+func (b *jsonBackend) Changed() (bool, error) {
+	if !b.loaded || b.seen == nil {
+		return true, nil
+	}
+
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return true, nil
+	}
+
+	same := os.SameFile(b.seen, info) && info.ModTime().Equal(b.seen.ModTime()) && info.Size() == b.seen.Size()
+	return !same, nil
+}
+
+func readNotesFile(path string) ([]Note, error) {
+	var notes []Note
+
+	notesJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading json file: %w", err)
+	}
+
+	err = json.Unmarshal(notesJSON, &notes)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling json file: %w", err)
+	}
+
+	return notes, nil
+}
+
+// recover restores the newest backup that still reads after the main file failed with loadErr.
+// The damaged file is kept next to it as biji.json.corrupt-<time> rather than thrown away.
+func (b *jsonBackend) recover(loadErr error) ([]Note, error) {
+	for n := 1; n <= backupCount; n++ {
+		backup := b.backupPath(n)
+
+		notes, err := readNotesFile(backup)
+		if err != nil {
+			continue
+		}
+
+		corrupt := fmt.Sprintf("%s.corrupt-%s", b.path, time.Now().Format("20060102-150405"))
+		if err := os.Rename(b.path, corrupt); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("error moving damaged json file aside: %w", err)
+		}
+		if err := copyFile(backup, b.path); err != nil {
+			return nil, fmt.Errorf("error restoring %s: %w", backup, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: %v, restored notes from %s\n", loadErr, backup)
+
+		return notes, nil
+	}
+
+	return nil, loadErr
+}
+
+func (b *jsonBackend) backupPath(n int) string {
+	return fmt.Sprintf("%s.bak.%d", b.path, n)
+}
+
+// rotateBackups shifts the backups down by one and makes the current file the newest.
+func (b *jsonBackend) rotateBackups() error {
+	if _, err := os.Stat(b.path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	for n := backupCount; n > 1; n-- {
+		err := os.Rename(b.backupPath(n-1), b.backupPath(n))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// The atomic write replaces the file rather than changing it, so a hard link
+	// keeps the current version around without copying it
+	os.Remove(b.backupPath(1))
+	if err := os.Link(b.path, b.backupPath(1)); err != nil {
+		return copyFile(b.path, b.backupPath(1))
+	}
+
+	return nil
+}
+
+func (b *jsonBackend) Put(notes ...Note) error {
+	if err := b.ensureLoaded(); err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		found := false
+		for i := range b.notes {
+			if b.notes[i].ID == note.ID {
+				b.notes[i] = note
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			b.notes = append(b.notes, note)
+		}
+	}
+
+	return b.save()
+}
+
+func (b *jsonBackend) Remove(ids ...string) error {
+	if err := b.ensureLoaded(); err != nil {
+		return err
+	}
+
+	before := len(b.notes)
+	b.notes = slices.DeleteFunc(b.notes, func(note Note) bool {
+		return slices.Contains(ids, note.ID)
+	})
+
+	if len(b.notes) == before {
+		return nil
+	}
+
+	return b.save()
+}
+
+// Lookup and LookupName go through the notes one by one, a single file has no index.
+// Both look at the notes as of the last Load or save.
+func (b *jsonBackend) Lookup(id string) (Note, bool, error) {
+	for _, note := range b.notes {
+		if note.ID == id {
+			return note, true, nil
+		}
+	}
+	return Note{}, false, nil
+}
+
+func (b *jsonBackend) LookupName(name string) ([]Note, error) {
+	var named []Note
+	for _, note := range b.notes {
+		if note.Name == name {
+			named = append(named, note)
+		}
+	}
+	return named, nil
+}
+
+func (b *jsonBackend) Close() error {
+	return nil
+}
+
+// ensureLoaded reads the file before the first write so it isn't overwritten
+// with only the notes that changed.
+func (b *jsonBackend) ensureLoaded() error {
+	if b.loaded {
+		return nil
+	}
+	_, err := b.Load()
+	return err
+}
+
+func (b *jsonBackend) save() error {
+	jsonData, err := json.Marshal(b.notes)
+	if err != nil {
+		return fmt.Errorf("error marshalling json file: %w", err)
+	}
+
+	if err := b.rotateBackups(); err != nil {
+		return fmt.Errorf("error backing up json file: %w", err)
+	}
+
+	if err := WriteFileAtomic(b.path, jsonData, 0o644); err != nil {
+		return fmt.Errorf("error saving json file: %w", err)
+	}
+	b.seen, _ = os.Stat(b.path)
+
+	return nil
+}
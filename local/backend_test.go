@@ -0,0 +1,80 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONBackend_KeepsRollingBackups(t *testing.T) {
+	dir := t.TempDir()
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	for _, name := range []string{"one", "two", "three", "four", "five"} {
+		if _, err := store.AddNote(name, ""); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "biji.json.bak.*"))
+	if len(backups) != backupCount {
+		t.Errorf("Expected %d backups, got %d", backupCount, len(backups))
+	}
+
+	// The newest backup is the file as it was before the last write
+	notes, err := readNotesFile(filepath.Join(dir, "biji.json.bak.1"))
+	if err != nil {
+		t.Fatalf("Failed to read newest backup: %v", err)
+	}
+	if len(notes) != 4 {
+		t.Errorf("Expected 4 notes in the newest backup, got %d", len(notes))
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(dir, ".biji.json.tmp-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("Expected no temp files after writing, got %v", leftovers)
+	}
+}
+
+func TestJSONBackend_RecoversFromBackup(t *testing.T) {
+	dir := t.TempDir()
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	if _, err := store.AddNote("first", "kept"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.AddNote("second", "also kept"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	// Simulate a write that died halfway through
+	dataFile := filepath.Join(dir, "biji.json")
+	if err := os.WriteFile(dataFile, []byte(`[{"id":"trunc`), 0o644); err != nil {
+		t.Fatalf("Failed to corrupt biji.json: %v", err)
+	}
+
+	recovered := &Store{}
+	if err := recovered.InitAt(dir); err != nil {
+		t.Fatalf("Expected Init to recover from backup, got: %v", err)
+	}
+
+	if len(recovered.Notes) != 1 || recovered.Notes[0].Name != "first" {
+		t.Errorf("Expected the note from the newest backup, got %v", recovered.Notes)
+	}
+
+	if _, err := readNotesFile(dataFile); err != nil {
+		t.Errorf("Expected biji.json to be restored, got: %v", err)
+	}
+
+	corrupt, _ := filepath.Glob(dataFile + ".corrupt-*")
+	if len(corrupt) != 1 {
+		t.Errorf("Expected the damaged file to be kept aside, got %v", corrupt)
+	}
+}
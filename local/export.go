@@ -0,0 +1,331 @@
+package local
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dallas1295/biji/markdown"
+)
+
+// ExportFormat is the kind of file a note is exported as.
+type ExportFormat string
+
+const (
+	FormatMarkdown  ExportFormat = "md"    // the content under front matter, what import reads back
+	FormatHTML      ExportFormat = "html"  // a web page
+	FormatPrintable ExportFormat = "print" // a web page styled for printing or saving as PDF
+	FormatJSON      ExportFormat = "json"  // the whole note with every field and its older versions
+	FormatText      ExportFormat = "txt"   // the name and content as plain text
+)
+
+// ExportFormats are the formats in the order help text lists them.
+var ExportFormats = []ExportFormat{FormatMarkdown, FormatHTML, FormatPrintable, FormatJSON, FormatText}
+
+// ParseExportFormat maps a format name, or a common alias like markdown, to a format.
+func ParseExportFormat(name string) (ExportFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "", "md", "markdown":
+		return FormatMarkdown, nil
+	case "html":
+		return FormatHTML, nil
+	case "print", "printable":
+		return FormatPrintable, nil
+	case "json":
+		return FormatJSON, nil
+	case "txt", "text":
+		return FormatText, nil
+	default:
+		return "", fmt.Errorf("unknown export format: %s, use md, html, print, json or txt", name)
+	}
+}
+
+// Ext is the file extension for the format.
+func (f ExportFormat) Ext() string {
+	if f == FormatPrintable {
+		return ".html"
+	}
+	return "." + string(f)
+}
+
+// DefaultExportDir is where exports go when no destination is given, ~/Documents.
+func DefaultExportDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user's home directory: %w", err)
+	}
+
+	return filepath.Join(home, "Documents"), nil
+}
+
+// ExportNote writes the note with id into the directory dir as a file in format.
+// It returns the path of the file.
+func (s *Store) ExportNote(id, dir string, format ExportFormat) (string, error) {
+	paths, err := s.ExportNotes([]string{id}, dir, format)
+	if err != nil {
+		return "", err
+	}
+
+	return paths[0], nil
+}
+
+// ExportNotes writes the notes with ids into the directory dir as files in format and
+// returns their paths, in the order of ids. Notes that would get the same file, like todo
+// in two notebooks, get a numbered suffix as in ExportAll instead of overwriting each other.
+func (s *Store) ExportNotes(ids []string, dir string, format ExportFormat) ([]string, error) {
+	used := make(map[string]bool)
+	files := make(map[string]string, len(ids)) // by ID, a note asked for twice is written once
+	paths := make([]string, 0, len(ids))
+	for _, id := range ids {
+		file, ok := files[id]
+		if !ok {
+			note, err := s.GetNoteFromID(id)
+			if err != nil {
+				return nil, fmt.Errorf("could not export note: %w", err)
+			}
+			if note, err = s.withHistory(note, format); err != nil {
+				return nil, err
+			}
+
+			file = uniquePath(used, "", safeFileName(note.Name), format.Ext())
+			if err := exportFile(note, filepath.Join(dir, file), format); err != nil {
+				return nil, err
+			}
+			files[id] = file
+		}
+
+		paths = append(paths, filepath.Join(dir, file))
+	}
+
+	return paths, nil
+}
+
+// withHistory adds the older versions of the note when it's exported as JSON, the one
+// format with room for them.
+func (s *Store) withHistory(note Note, format ExportFormat) (Note, error) {
+	if format != FormatJSON {
+		return note, nil
+	}
+
+	history, err := s.readHistory(note.ID)
+	if err != nil {
+		return Note{}, fmt.Errorf("could not export note: %w", err)
+	}
+	note.History = history
+
+	return note, nil
+}
+
+func exportFile(note Note, filePath string, format ExportFormat) error {
+	var buf bytes.Buffer
+	if err := WriteNote(&buf, note, format); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filePath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("could not export note: %w", err)
+	}
+	// Keep the note's time on the file so an import gets it back
+	os.Chtimes(filePath, note.ModifiedAt, note.ModifiedAt)
+
+	return nil
+}
+
+// exportManifest is the manifest.json at the top of an ExportAll archive, it says which
+// note every file holds.
+type exportManifest struct {
+	Format   ExportFormat    `json:"format"`
+	Exported time.Time       `json:"exported"`
+	Notes    []manifestEntry `json:"notes"`
+}
+
+type manifestEntry struct {
+	File     string `json:"file"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Notebook string `json:"notebook,omitempty"`
+}
+
+// ExportAll writes every note to w as a zip archive of files in format, with a folder for
+// every notebook and a manifest.json mapping the files to note IDs. In the HTML formats
+// [[links]] lead to the linked note's file.
+func (s *Store) ExportAll(w io.Writer, format ExportFormat) error {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	paths := exportPaths(s.Notes, format)
+	notes := slices.Clone(s.Notes)
+	slices.SortFunc(notes, func(a, b Note) int { return strings.Compare(paths[a.ID], paths[b.ID]) })
+
+	manifest := exportManifest{Format: format, Exported: time.Now().UTC()}
+	archive := zip.NewWriter(w)
+	for _, note := range notes {
+		header := &zip.FileHeader{
+			Name:     paths[note.ID],
+			Method:   zip.Deflate,
+			Modified: note.ModifiedAt,
+		}
+		file, err := archive.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to zip: %w", header.Name, err)
+		}
+
+		note, err = s.withHistory(note, format)
+		if err != nil {
+			return err
+		}
+		if err := writeNote(file, note, format, s.exportLinker(note, paths)); err != nil {
+			return err
+		}
+
+		manifest.Notes = append(manifest.Notes, manifestEntry{
+			File:     header.Name,
+			ID:       note.ID,
+			Name:     note.Name,
+			Notebook: note.Notebook,
+		})
+	}
+
+	file, err := archive.Create(manifestFile)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to zip: %w", manifestFile, err)
+	}
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("error writing %s: %w", manifestFile, err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to create zip file: %w", err)
+	}
+
+	return nil
+}
+
+// ExportAllTo writes the archive from ExportAll to the file at target. The file is only
+// replaced once the whole export succeeded.
+func (s *Store) ExportAllTo(target string, format ExportFormat) error {
+	var buf bytes.Buffer
+	if err := s.ExportAll(&buf, format); err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(target, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to save export: %w", err)
+	}
+
+	return nil
+}
+
+// exportLinker returns the relative href from note's file to the file of the note a link
+// target leads to, paths being the files from exportPaths. The caller must hold a lock.
+func (s *Store) exportLinker(note Note, paths map[string]string) func(string) string {
+	return func(target string) string {
+		id, ok := s.resolveLink(target)
+		if !ok {
+			return ""
+		}
+
+		from := filepath.FromSlash(path.Dir(paths[note.ID]))
+		rel, err := filepath.Rel(from, filepath.FromSlash(paths[id]))
+		if err != nil {
+			return ""
+		}
+
+		return (&url.URL{Path: filepath.ToSlash(rel)}).String()
+	}
+}
+
+// WriteNote writes a note to w in format. [[links]] aren't followed, ExportAll is what
+// links exported notes to each other.
+func WriteNote(w io.Writer, note Note, format ExportFormat) error {
+	return writeNote(w, note, format, nil)
+}
+
+func writeNote(w io.Writer, note Note, format ExportFormat, link func(string) string) error {
+	var err error
+	switch format {
+	case FormatMarkdown:
+		_, err = io.WriteString(w, frontMatter(note)+"\n"+strings.TrimRight(note.Content, "\n")+"\n")
+	case FormatText:
+		_, err = io.WriteString(w, note.Name+"\n\n"+note.taggedContent())
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(note)
+	case FormatHTML, FormatPrintable:
+		err = noteTemplate.Execute(w, htmlNote{
+			Note:     note,
+			Tags:     note.AllTags(),
+			Body:     template.HTML(markdown.HTML(note.Content, link)),
+			Print:    format == FormatPrintable,
+			Created:  note.CreatedAt.Local().Format(time.DateTime),
+			Modified: note.ModifiedAt.Local().Format(time.DateTime),
+		})
+	default:
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", note.Name, err)
+	}
+
+	return nil
+}
+
+type htmlNote struct {
+	Note              Note
+	Tags              []string
+	Body              template.HTML
+	Print             bool
+	Created, Modified string
+}
+
+var noteTemplate = template.Must(template.New("note").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Note.Name}}</title>
+<style>
+{{- if .Print}}
+@page { margin: 2cm; }
+body { font: 11pt/1.5 Georgia, "Times New Roman", serif; color: #000; }
+a { color: #000; }
+a[href^="http"]::after { content: " (" attr(href) ")"; font-size: 9pt; }
+pre, blockquote, li { break-inside: avoid; }
+h1, h2, h3 { break-after: avoid; }
+{{- else}}
+body { font: 16px/1.6 system-ui, sans-serif; max-width: 46rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+a { color: #2563eb; }
+{{- end}}
+.meta { color: #666; font-size: 0.85em; }
+.link { text-decoration: underline dotted; }
+pre { background: #f4f4f4; padding: 0.75em; overflow-x: auto; }
+blockquote { border-left: 3px solid #ccc; margin-left: 0; padding-left: 1em; color: #555; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; }
+</style>
+</head>
+<body>
+<article>
+<h1>{{.Note.Name}}</h1>
+<p class="meta">
+{{- with .Note.Notebook}}{{.}} &middot; {{end -}}
+Created {{.Created}} &middot; Modified {{.Modified}}
+{{- range .Tags}} #{{.}}{{end -}}
+</p>
+{{.Body}}</article>
+</body>
+</html>
+`))
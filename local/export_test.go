@@ -0,0 +1,193 @@
+package local
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteNote_Formats(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := store.AddNoteTo("work", "plan", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	updated, err := store.UpdateNoteContent(note.ID, "second")
+	if err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteNote(&buf, updated, FormatJSON); err != nil {
+		t.Fatalf("Failed to write json: %v", err)
+	}
+	var decoded Note
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to read json back: %v", err)
+	}
+	if decoded.ID != updated.ID || decoded.Notebook != "work" || decoded.Version != updated.Version {
+		t.Errorf("Expected every field in the json, got %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := WriteNote(&buf, updated, FormatText); err != nil {
+		t.Fatalf("Failed to write text: %v", err)
+	}
+	if buf.String() != "plan\n\nsecond" {
+		t.Errorf("Expected name and content, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteNote(&buf, updated, FormatPrintable); err != nil {
+		t.Fatalf("Failed to write printable html: %v", err)
+	}
+	if !strings.Contains(buf.String(), "@page") || !strings.Contains(buf.String(), "<p>second</p>") {
+		t.Errorf("Expected print styles and the content, got %s", buf.String())
+	}
+
+	if _, err := ParseExportFormat("pdf"); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+}
+
+func TestExportJSON_KeepsHistory(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := store.AddNote("plan", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.UpdateNoteContent(note.ID, "second"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+
+	paths, err := store.ExportNotes([]string{note.ID}, t.TempDir(), FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to export note: %v", err)
+	}
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	var exported Note
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Failed to read json back: %v", err)
+	}
+	if len(exported.History) != 1 || exported.History[0].Content != "first" {
+		t.Errorf("Expected the first version in the export, got %+v", exported.History)
+	}
+
+	var buf bytes.Buffer
+	if err := store.ExportAll(&buf, FormatJSON); err != nil {
+		t.Fatalf("Failed to export all: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	file, err := archive.Open("plan.json")
+	if err != nil {
+		t.Fatalf("Failed to open plan.json: %v", err)
+	}
+	defer file.Close()
+	exported = Note{}
+	if err := json.NewDecoder(file).Decode(&exported); err != nil {
+		t.Fatalf("Failed to read json back: %v", err)
+	}
+	if len(exported.History) != 1 {
+		t.Errorf("Expected the first version in the archive, got %+v", exported.History)
+	}
+}
+
+func TestExportNotes_SameNameInTwoNotebooks(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	a, err := store.AddNoteTo("a", "todo", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	b, err := store.AddNoteTo("b", "todo", "second")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	dir := t.TempDir()
+	paths, err := store.ExportNotes([]string{a.ID, b.ID, a.ID}, dir, FormatText)
+	if err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	want := []string{filepath.Join(dir, "todo.txt"), filepath.Join(dir, "todo-2.txt"), filepath.Join(dir, "todo.txt")}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("Expected %v, got %v", want, paths)
+	}
+
+	for i, content := range []string{"first", "second"} {
+		data, err := os.ReadFile(paths[i])
+		if err != nil {
+			t.Fatalf("Failed to read export: %v", err)
+		}
+		if !strings.HasSuffix(string(data), content) {
+			t.Errorf("Expected %s to hold %q, got %q", paths[i], content, data)
+		}
+	}
+}
+
+func TestExportAll_HTMLLinksBetweenNotes(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	if _, err := store.AddNoteTo("work/projects", "big plan", "see [[ideas|the ideas]] and [[missing]]"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.AddNote("ideas", "<script>alert(1)</script>"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := store.ExportAll(&buf, FormatHTML); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open export: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	plan, ok := files["work/projects/big_plan.html"]
+	if !ok {
+		t.Fatalf("Expected the note in its notebook folder, got %v", files)
+	}
+	if !strings.Contains(plan, `<a href="../../ideas.html">the ideas</a>`) {
+		t.Errorf("Expected a relative link to the other note, got %s", plan)
+	}
+	if !strings.Contains(plan, `<span class="link">missing</span>`) {
+		t.Errorf("Expected the broken link left as text, got %s", plan)
+	}
+	if strings.Contains(files["ideas.html"], "<script>") {
+		t.Errorf("Expected the content escaped, got %s", files["ideas.html"])
+	}
+}
@@ -0,0 +1,112 @@
+package local
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxFileNameBytes keeps file names under the 255 byte limit most file systems have, with
+// room left for a -N suffix and the extension.
+const maxFileNameBytes = 200
+
+// manifestFile sits at the top of an ExportAll archive, no note file can take its name.
+const manifestFile = "manifest.json"
+
+// reservedNames can't be file names on Windows, whatever the extension.
+var reservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// safeFileName turns a note or notebook name into a file name that works on every OS,
+// without an extension. Spaces become underscores as they always have, characters
+// Windows or a path would choke on become dashes, and the result is NFC normalized so
+// the same name typed on two systems gives the same file.
+func safeFileName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFC.String(name) {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			continue
+		case r == ' ':
+			b.WriteRune('_')
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	// A leading dot hides the file, Windows drops a trailing one
+	safe := strings.Trim(b.String(), ".")
+
+	if len(safe) > maxFileNameBytes {
+		cut := maxFileNameBytes
+		for cut > 0 && !utf8.RuneStart(safe[cut]) {
+			cut--
+		}
+		safe = strings.TrimRight(safe[:cut], ".")
+	}
+
+	if safe == "" {
+		return "untitled"
+	}
+	base, _, _ := strings.Cut(safe, ".")
+	if slices.Contains(reservedNames, strings.ToUpper(base)) {
+		safe = "_" + safe
+	}
+
+	return safe
+}
+
+// safeNotebookDir is the folder a notebook is exported to, every part made safe.
+func safeNotebookDir(notebook string) string {
+	if notebook == "" {
+		return ""
+	}
+
+	parts := strings.Split(notebook, "/")
+	for i, part := range parts {
+		parts[i] = safeFileName(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// exportPaths gives every note a slash separated path, unique even on file systems that
+// ignore case. Notes whose paths clash are numbered oldest first, so exporting the same
+// notes twice gives the same files.
+func exportPaths(notes []Note, format ExportFormat) map[string]string {
+	ordered := slices.Clone(notes)
+	slices.SortFunc(ordered, func(a, b Note) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	paths := make(map[string]string, len(ordered))
+	used := map[string]bool{manifestFile: true}
+	for _, note := range ordered {
+		paths[note.ID] = uniquePath(used, safeNotebookDir(note.Notebook), safeFileName(note.Name), format.Ext())
+	}
+
+	return paths
+}
+
+// uniquePath returns dir/base+ext, or dir/base-N+ext with the first N that isn't used yet,
+// and marks it used. Case doesn't make paths different, as on macOS and Windows.
+func uniquePath(used map[string]bool, dir, base, ext string) string {
+	p := path.Join(dir, base+ext)
+	for n := 2; used[strings.ToLower(p)]; n++ {
+		p = path.Join(dir, fmt.Sprintf("%s-%d%s", base, n, ext))
+	}
+
+	used[strings.ToLower(p)] = true
+	return p
+}
@@ -0,0 +1,130 @@
+package local
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSafeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"shopping list", "shopping_list"},
+		{"a/b: c?", "a-b-_c-"},
+		{"<con>", "-con-"},
+		{"con", "_con"},
+		{"Lpt1.notes", "_Lpt1.notes"},
+		{".hidden.", "hidden"},
+		{"tab\there", "tabhere"},
+		{"...", "untitled"},
+		{"café", "café"},
+		{strings.Repeat("é", 150), strings.Repeat("é", 100)},
+	}
+
+	for _, tt := range tests {
+		if got := safeFileName(tt.name); got != tt.want {
+			t.Errorf("safeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExportPaths_Deduplicates(t *testing.T) {
+	now := time.Now()
+	notes := []Note{
+		{ID: "3", Name: "Plan", CreatedAt: now.Add(2 * time.Second)},
+		{ID: "1", Name: "a b", CreatedAt: now},
+		{ID: "2", Name: "a_b", CreatedAt: now.Add(time.Second)},
+		{ID: "4", Name: "plan", CreatedAt: now},
+		{ID: "5", Name: "manifest", CreatedAt: now},
+		{ID: "6", Notebook: "x:y", Name: "a b", CreatedAt: now},
+	}
+
+	want := map[string]string{
+		"1": "a_b.json",
+		"2": "a_b-2.json",
+		"4": "plan.json",
+		"3": "Plan-2.json",
+		"5": "manifest-2.json",
+		"6": "x-y/a_b.json",
+	}
+	for range 2 {
+		paths := exportPaths(notes, FormatJSON)
+		for id, p := range want {
+			if paths[id] != p {
+				t.Errorf("Expected note %s at %s, got %s", id, p, paths[id])
+			}
+		}
+		// The same paths whatever order the notes come in
+		notes[0], notes[5] = notes[5], notes[0]
+	}
+}
+
+func TestExportAll_ManifestAndAwkwardNames(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "export.zip")
+
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	var ids []string
+	for _, name := range []string{"a b", "a_b", `Q3\Q4: goals?`} {
+		note, err := store.AddNoteTo("team: ops", name, "content of "+name)
+		if err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		ids = append(ids, note.ID)
+	}
+
+	if err := store.ExportAllTo(target, FormatMarkdown); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	zr, err := zip.OpenReader(target)
+	if err != nil {
+		t.Fatalf("Failed to open export: %v", err)
+	}
+	defer zr.Close()
+
+	var manifest exportManifest
+	for _, f := range zr.File {
+		if f.Name != manifestFile {
+			continue
+		}
+		rc, _ := f.Open()
+		err := json.NewDecoder(rc).Decode(&manifest)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read manifest: %v", err)
+		}
+	}
+	if len(manifest.Notes) != 3 || len(zr.File) != 4 {
+		t.Fatalf("Expected a file and manifest entry per note, got %+v", manifest)
+	}
+	files := make(map[string]string)
+	for _, entry := range manifest.Notes {
+		files[entry.ID] = entry.File
+	}
+	if files[ids[0]] != "team-_ops/a_b.md" || files[ids[1]] != "team-_ops/a_b-2.md" || files[ids[2]] != "team-_ops/Q3-Q4-_goals-.md" {
+		t.Errorf("Unexpected files: %v", files)
+	}
+
+	restored := &Store{}
+	if err := restored.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	if _, err := restored.Import(target, SkipExisting); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	for _, id := range ids {
+		want, _ := store.GetNoteFromID(id)
+		got, err := restored.GetNoteFromID(id)
+		if err != nil || got.Path() != want.Path() {
+			t.Errorf("Expected %s back, got %q (%v)", want.Path(), got.Path(), err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package local
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// splitFrontMatter cuts a YAML front matter block, between --- lines at the very top, off
+// content. Only the plain YAML notes use is understood: key: value, key: [a, b] and lists
+// of - item lines. Every value comes back as a list, a plain value being a list of one.
+func splitFrontMatter(content string) (map[string][]string, string, bool) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if strings.TrimSpace(lines[0]) != "---" {
+		return nil, content, false
+	}
+	end := slices.IndexFunc(lines[1:], func(line string) bool {
+		line = strings.TrimSpace(line)
+		return line == "---" || line == "..."
+	})
+	if end == -1 {
+		return nil, content, false
+	}
+	end++
+
+	fields := make(map[string][]string)
+	var key string
+	for _, line := range lines[1:end] {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if item, ok := strings.CutPrefix(line, "- "); ok {
+			if key != "" {
+				fields[key] = append(fields[key], unquote(item))
+			}
+			continue
+		}
+
+		k, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(k))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case value == "":
+			fields[key] = nil
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			fields[key] = nil
+			for item := range strings.SplitSeq(value[1:len(value)-1], ",") {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					fields[key] = append(fields[key], item)
+				}
+			}
+		default:
+			fields[key] = []string{unquote(value)}
+		}
+	}
+
+	return fields, strings.TrimLeft(strings.Join(lines[end+1:], "\n"), "\n"), true
+}
+
+// unquote strips the quotes around a YAML string, undoing the escapes in a double
+// quoted one and the doubled quotes in a single quoted one.
+func unquote(value string) string {
+	if len(value) < 2 || value[len(value)-1] != value[0] {
+		return value
+	}
+
+	switch value[0] {
+	case '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+		return value[1 : len(value)-1]
+	case '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+
+	return value
+}
+
+// frontMatter is the YAML block a Markdown export starts with, it holds what an import needs
+// to bring the note back as the same note. Tags are the explicit ones, #hashtags stay in
+// the content.
+func frontMatter(note Note) string {
+	var b strings.Builder
+
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "id: %s\n", note.ID)
+	fmt.Fprintf(&b, "name: %s\n", yamlString(note.Name))
+	if note.Notebook != "" {
+		fmt.Fprintf(&b, "notebook: %s\n", yamlString(note.Notebook))
+	}
+	fmt.Fprintf(&b, "created: %s\n", note.CreatedAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "modified: %s\n", note.ModifiedAt.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "version: %d\n", note.Version)
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(note.Tags, ", "))
+	}
+	if note.Pinned {
+		fmt.Fprintf(&b, "pinned: %d\n", note.Position)
+	}
+	if note.Favorite {
+		b.WriteString("favorite: true\n")
+	}
+	b.WriteString("---\n")
+
+	return b.String()
+}
+
+// yamlString quotes a value when YAML would read it as something other than the same
+// string, a number, a list or a value with a comment in it.
+func yamlString(value string) string {
+	plain := value != "" &&
+		value == strings.TrimSpace(value) &&
+		!strings.ContainsAny(value, ":#[]{},&*!|>'\"%@`\\") &&
+		!strings.ContainsAny(value[:1], "-?") &&
+		!slices.Contains([]string{"true", "false", "yes", "no", "on", "off", "null", "~"}, strings.ToLower(value))
+	if _, err := strconv.ParseFloat(value, 64); plain && err != nil {
+		return value
+	}
+	return strconv.Quote(value)
+}
@@ -0,0 +1,334 @@
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxHistory caps how many older versions a note keeps and maxHistoryBytes how much
+// name and content they add up to, the oldest are dropped first.
+const (
+	maxHistory      = 100
+	maxHistoryBytes = 1 << 20
+)
+
+// historyDir holds a file of older versions for every note that has them, next to the
+// notes. Kept apart, history isn't rewritten with every save or sent with every sync.
+const historyDir = "history"
+
+// Revision is a note as it was at one Version.
+type Revision struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Content    string    `json:"content"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+}
+
+// DiffLine is one line of a diff, Op is ' ' for unchanged, '-' for removed and '+' for added.
+type DiffLine struct {
+	Op   rune
+	Text string
+}
+
+func (d DiffLine) String() string {
+	return string(d.Op) + " " + d.Text
+}
+
+// newVersion returns a copy of the note with Version bumped, ready to be changed and
+// saved. The version it replaces goes into the history when it's saved, see put.
+func (n Note) newVersion() Note {
+	n.Version++
+	n.ModifiedAt = time.Now()
+
+	return n
+}
+
+func (n Note) revision() Revision {
+	return Revision{
+		Version:    n.Version,
+		Name:       n.Name,
+		Content:    n.Content,
+		ModifiedAt: n.ModifiedAt,
+	}
+}
+
+func (r Revision) size() int {
+	return len(r.Name) + len(r.Content)
+}
+
+// History returns every kept version of a note, oldest first, ending with the current one.
+func (s *Store) History(id string) ([]Revision, error) {
+	note, err := s.GetNoteFromID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.readHistory(id)
+	if err != nil {
+		return nil, err
+	}
+	return append(revisions, note.revision()), nil
+}
+
+// put saves notes to the backend, keeping what a note was called and said before in its
+// history when either changes. Versions that only changed tags, pins or the like
+// aren't worth keeping. The caller must hold the locks.
+func (s *Store) put(notes ...Note) error {
+	before := make(map[string]Note, len(notes))
+	for _, note := range notes {
+		before[note.ID] = Note{}
+	}
+	for _, note := range slices.Concat(s.Notes, s.Trash) {
+		if _, ok := before[note.ID]; ok {
+			before[note.ID] = note
+		}
+	}
+
+	notes = slices.Clone(notes)
+	for i, note := range notes {
+		// Notes from a device that hasn't moved its history out yet
+		notes[i].History = nil
+
+		old := before[note.ID]
+		if old.ID == "" || (old.Name == note.Name && old.Content == note.Content) {
+			continue
+		}
+		if err := s.keepRevisions(note.ID, old.revision()); err != nil {
+			return err
+		}
+	}
+
+	return s.backend.Put(notes...)
+}
+
+func (s *Store) historyPath(id string) string {
+	return filepath.Join(s.Dir(), historyDir, safeFileName(id)+".json")
+}
+
+// readHistory returns the older versions of the note with id, oldest first.
+func (s *Store) readHistory(id string) ([]Revision, error) {
+	data, err := os.ReadFile(s.historyPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	var revisions []Revision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("error unmarshalling history: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// keepRevisions adds revisions to the history of the note with id, dropping the oldest
+// past maxHistory or maxHistoryBytes. The newest is kept whatever its size.
+func (s *Store) keepRevisions(id string, revisions ...Revision) error {
+	history, err := s.readHistory(id)
+	if err != nil {
+		return err
+	}
+	history = append(history, revisions...)
+
+	size := 0
+	for _, rev := range history {
+		size += rev.size()
+	}
+	for len(history) > 1 && (len(history) > maxHistory || size > maxHistoryBytes) {
+		size -= history[0].size()
+		history = history[1:]
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("error marshalling history: %w", err)
+	}
+
+	path := s.historyPath(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+	if err := WriteFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("error saving history: %w", err)
+	}
+
+	return nil
+}
+
+// removeHistory deletes the history of notes that are gone for good.
+func (s *Store) removeHistory(ids ...string) {
+	for _, id := range ids {
+		os.Remove(s.historyPath(id))
+	}
+}
+
+// moveHistory moves the older versions notes kept inside them before history got its
+// own files out of the notes.
+func (s *Store) moveHistory() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Only stores saved before history had its own files have any to move, and once
+	// moved it's gone, so look before waiting on the file lock
+	if !slices.ContainsFunc(slices.Concat(s.Notes, s.Trash), func(n Note) bool { return len(n.History) > 0 }) {
+		return nil
+	}
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var moved []Note
+	for _, note := range slices.Concat(s.Notes, s.Trash) {
+		if len(note.History) == 0 {
+			continue
+		}
+		if err := s.keepRevisions(note.ID, note.History...); err != nil {
+			return err
+		}
+		note.History = nil
+		moved = append(moved, note)
+	}
+
+	return s.putAll(moved)
+}
+
+// GetRevision returns a single version of a note, which can be the current one.
+func (s *Store) GetRevision(id string, version int) (Revision, error) {
+	revisions, err := s.History(id)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	for _, rev := range revisions {
+		if rev.Version == version {
+			return rev, nil
+		}
+	}
+
+	return Revision{}, fmt.Errorf("note has no version %d: %w", version, ErrNotFound)
+}
+
+// DiffRevisions compares the content of two versions of a note line by line.
+func (s *Store) DiffRevisions(id string, from, to int) ([]DiffLine, error) {
+	fromRev, err := s.GetRevision(id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := s.GetRevision(id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffLines(fromRev.Content, toRev.Content), nil
+}
+
+// RevertNote restores the name and content of an older version. The revert is saved
+// as a new version, so it can be undone the same way.
+func (s *Store) RevertNote(id string, version int) (Note, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
+
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+
+	current := s.Notes[i]
+	if current.Version == version {
+		return current, nil
+	}
+
+	history, err := s.readHistory(id)
+	if err != nil {
+		return Note{}, err
+	}
+
+	var target *Revision
+	for _, rev := range history {
+		if rev.Version == version {
+			target = &rev
+			break
+		}
+	}
+	if target == nil {
+		return Note{}, fmt.Errorf("note has no version %d: %w", version, ErrNotFound)
+	}
+
+	if s.nameTaken(current.Notebook, target.Name, id) {
+		return Note{}, fmt.Errorf("name: %s, %w", target.Name, ErrNameTaken)
+	}
+
+	updated := current.newVersion()
+	updated.Name = target.Name
+	updated.Content = target.Content
+
+	if err := s.put(updated); err != nil {
+		return Note{}, err
+	}
+	s.Notes[i] = updated
+	s.reindex(updated)
+
+	return updated, nil
+}
+
+// diffLines is a plain LCS diff, notes are small enough that the quadratic table is fine.
+func diffLines(a, b string) []DiffLine {
+	aLines := strings.Split(a, "\n")
+	bLines := strings.Split(b, "\n")
+	n, m := len(aLines), len(bLines)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if aLines[i] == bLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []DiffLine
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case aLines[i] == bLines[j]:
+			diff = append(diff, DiffLine{' ', aLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{'-', aLines[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{'+', bLines[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		diff = append(diff, DiffLine{'-', aLines[i]})
+	}
+	for ; j < m; j++ {
+		diff = append(diff, DiffLine{'+', bLines[j]})
+	}
+
+	return diff
+}
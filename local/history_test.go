@@ -0,0 +1,187 @@
+package local
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdates_BumpVersionAndKeepHistory(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	note, err := store.AddNote("draft", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if note.Version != 1 {
+		t.Errorf("Expected new note at version 1, got %d", note.Version)
+	}
+
+	if _, err := store.UpdateNoteContent(note.ID, "second"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+	updated, err := store.UpdateNoteName(note.ID, "final")
+	if err != nil {
+		t.Fatalf("Failed to rename note: %v", err)
+	}
+	if updated.Version != 3 {
+		t.Errorf("Expected version 3 after two changes, got %d", updated.Version)
+	}
+
+	revisions, err := store.History(note.ID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(revisions) != 3 {
+		t.Fatalf("Expected 3 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Content != "first" || revisions[0].Name != "draft" {
+		t.Errorf("Expected the original as the oldest revision, got %+v", revisions[0])
+	}
+
+	diff, err := store.DiffRevisions(note.ID, 1, 3)
+	if err != nil {
+		t.Fatalf("Failed to diff revisions: %v", err)
+	}
+	if len(diff) != 2 || diff[0].String() != "- first" || diff[1].String() != "+ second" {
+		t.Errorf("Unexpected diff: %v", diff)
+	}
+}
+
+func TestRevertNote(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	note, err := store.AddNote("draft", "keep me")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.UpdateNoteContent(note.ID, "accidental overwrite"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+
+	reverted, err := store.RevertNote(note.ID, 1)
+	if err != nil {
+		t.Fatalf("Failed to revert note: %v", err)
+	}
+	if reverted.Content != "keep me" {
+		t.Errorf("Expected reverted content, got %q", reverted.Content)
+	}
+	revisions, err := store.History(note.ID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if reverted.Version != 3 || len(revisions) != 3 {
+		t.Errorf("Expected the revert saved as version 3 with 2 older versions, got v%d with %d", reverted.Version, len(revisions)-1)
+	}
+
+	if _, err := store.RevertNote(note.ID, 42); err == nil {
+		t.Error("Expected an error reverting to a version that doesn't exist")
+	}
+}
+
+func TestHistory_KeptApartFromNotes(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	note, err := store.AddNote("draft", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.UpdateNoteContent(note.ID, "second"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+	// Only tags and pins change, nothing worth a revision
+	if _, err := store.AddTags(note.ID, "work"); err != nil {
+		t.Fatalf("Failed to tag note: %v", err)
+	}
+	if _, err := store.SetFavorite(note.ID, true); err != nil {
+		t.Fatalf("Failed to favorite note: %v", err)
+	}
+
+	revisions, err := store.History(note.ID)
+	if err != nil {
+		t.Fatalf("Failed to get history: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Content != "first" {
+		t.Errorf("Expected the first version and the current one, got %+v", revisions)
+	}
+
+	data, err := os.ReadFile(filepath.Join(store.Dir(), "biji.json"))
+	if err != nil {
+		t.Fatalf("Failed to read notes: %v", err)
+	}
+	if strings.Contains(string(data), "history") || strings.Contains(string(data), "first") {
+		t.Errorf("Expected no history in biji.json, got %s", data)
+	}
+}
+
+func TestHistory_CapsSize(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	note, err := store.AddNote("big", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	chunk := strings.Repeat("x", maxHistoryBytes/4)
+	for i := range 8 {
+		if _, err := store.UpdateNoteContent(note.ID, chunk+strings.Repeat("y", i+1)); err != nil {
+			t.Fatalf("Failed to update note: %v", err)
+		}
+	}
+
+	history, err := store.readHistory(note.ID)
+	if err != nil {
+		t.Fatalf("Failed to read history: %v", err)
+	}
+	size := 0
+	for _, rev := range history {
+		size += rev.size()
+	}
+	if size > maxHistoryBytes || len(history) != 3 {
+		t.Errorf("Expected the newest 3 versions under %d bytes, got %d taking %d", maxHistoryBytes, len(history), size)
+	}
+}
+
+func TestHistory_MovedOutOfOldNotes(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	old := []Note{{
+		ID: "1a2b3c4d-0000-4000-8000-000000000001", Name: "draft", Content: "second",
+		CreatedAt: now, ModifiedAt: now, Version: 2,
+		History: []Revision{{Version: 1, Name: "draft", Content: "first", ModifiedAt: now}},
+	}}
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatalf("Failed to marshal notes: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "biji.json"), data, 0o644); err != nil {
+		t.Fatalf("Failed to write notes: %v", err)
+	}
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	if len(store.Notes[0].History) != 0 {
+		t.Error("Expected the history moved out of the note")
+	}
+	rev, err := store.GetRevision(old[0].ID, 1)
+	if err != nil || rev.Content != "first" {
+		t.Errorf("Expected version 1 kept, got %+v: %v", rev, err)
+	}
+}
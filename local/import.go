@@ -0,0 +1,461 @@
+package local
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CollisionPolicy says what Import does with a file whose note name is already taken.
+type CollisionPolicy int
+
+const (
+	SkipExisting      CollisionPolicy = iota // leave the existing note, don't import the file
+	RenameImported                           // import it with a numbered suffix
+	OverwriteExisting                        // replace the existing note's content
+)
+
+// ParseCollisionPolicy maps the names used on the command line to a policy.
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	switch strings.ToLower(name) {
+	case "", "skip":
+		return SkipExisting, nil
+	case "rename":
+		return RenameImported, nil
+	case "overwrite":
+		return OverwriteExisting, nil
+	default:
+		return 0, fmt.Errorf("unknown collision policy: %s, use skip, rename or overwrite", name)
+	}
+}
+
+// importExts are the files Import picks up, everything else is ignored.
+var importExts = []string{".md", ".markdown", ".txt"}
+
+// ImportResult sums up what Import did. Failed holds the files that couldn't be read.
+type ImportResult struct {
+	Imported    int
+	Updated     int // notes that came back with their ID and newer content
+	Renamed     int
+	Overwritten int
+	Skipped     int
+	Failed      []error
+}
+
+// importedNote is a note read from an export, before it's matched against the store.
+type importedNote struct {
+	id       string // kept when the export had one, so the note comes back as itself
+	version  int
+	position int // set for a pinned note
+	favorite bool
+	notebook string
+	name     string
+	content  string
+	tags     []string
+	created  time.Time
+	modified time.Time
+	deleted  time.Time // set for notes that were in the other app's trash
+}
+
+// importer reads the notes out of an export at src. Entries it can't read go in the
+// returned errors, the error is for when src can't be read at all.
+type importer func(src string) ([]importedNote, []error, error)
+
+// importers are the formats ImportFrom knows, by the name used on the command line.
+var importers = map[string]importer{
+	"markdown":   readMarkdown,
+	"obsidian":   readObsidian,
+	"joplin":     readJoplin,
+	"simplenote": readSimplenote,
+}
+
+// ImportFormats returns the formats ImportFrom accepts.
+func ImportFormats() []string {
+	return slices.Sorted(maps.Keys(importers))
+}
+
+// Import reads the Markdown and text files in a directory or zip archive, such as the one
+// ExportAll writes, into notes. Front matter gives a note back its ID, name, times and tags.
+// Without it folders become notebooks, names come from the file names with underscores
+// turned back into spaces, and the file times become CreatedAt and ModifiedAt.
+func (s *Store) Import(src string, policy CollisionPolicy) (ImportResult, error) {
+	return s.ImportFrom("markdown", src, policy)
+}
+
+// ImportFrom reads the export at src, in one of the ImportFormats, into notes. A note whose
+// front matter ID is already in the store is the same note, updated when the file is newer.
+// Names taken by other notes are handled by policy, names repeated within the export are
+// always given a numbered suffix so nothing in it is lost.
+func (s *Store) ImportFrom(format, src string, policy CollisionPolicy) (ImportResult, error) {
+	read, ok := importers[strings.ToLower(format)]
+	if !ok {
+		return ImportResult{}, fmt.Errorf("unknown import format: %s, use one of %s", format, strings.Join(ImportFormats(), ", "))
+	}
+
+	files, failed, err := read(src)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	result, err := s.importNotes(files, policy)
+	result.Failed = append(failed, result.Failed...)
+
+	return result, err
+}
+
+func readMarkdown(src string) ([]importedNote, []error, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening %s: %w", src, err)
+	}
+
+	switch {
+	case info.IsDir():
+		return readImportDir(os.DirFS(src), parseImportedFile)
+	case strings.EqualFold(filepath.Ext(src), ".zip"):
+		return readImportZip(src)
+	default:
+		return readImportFile(src, info)
+	}
+}
+
+func (s *Store) importNotes(files []importedNote, policy CollisionPolicy) (ImportResult, error) {
+	var result ImportResult
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+
+	var notes []Note
+	updated := make(map[string]bool)
+	inBatch := func(id string) bool {
+		return slices.ContainsFunc(notes, func(n Note) bool { return n.ID == id })
+	}
+	// Names taken by live notes earlier in this import count as taken too
+	taken := func(notebook, name, id string) bool {
+		return s.nameTaken(notebook, name, id) || slices.ContainsFunc(notes, func(n Note) bool {
+			return n.Notebook == notebook && n.Name == name && n.DeletedAt.IsZero() && n.ID != id
+		})
+	}
+	numbered := func(note Note) Note {
+		base := note.Name
+		for n := 2; taken(note.Notebook, note.Name, note.ID); n++ {
+			note.Name = fmt.Sprintf("%s (%d)", base, n)
+		}
+		return note
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		if file.modified.IsZero() {
+			file.modified = now
+		}
+		if file.created.IsZero() {
+			file.created = file.modified
+		}
+
+		note := Note{
+			ID:         file.id,
+			Notebook:   file.notebook,
+			Name:       file.name,
+			Content:    file.content,
+			Tags:       file.tags,
+			CreatedAt:  file.created,
+			ModifiedAt: file.modified,
+			Version:    max(file.version, 1),
+			Pinned:     file.position > 0,
+			Position:   file.position,
+			Favorite:   file.favorite,
+			DeletedAt:  file.deleted,
+		}
+
+		// A note exported from this store comes back as itself, changed if the file is newer
+		if i := s.noteIndex(note.ID); i != -1 && !inBatch(note.ID) {
+			existing := s.Notes[i]
+			same := existing.Name == note.Name && existing.Notebook == note.Notebook &&
+				existing.Content == note.Content && slices.Equal(existing.Tags, note.Tags) &&
+				existing.Pinned == note.Pinned && existing.Position == note.Position && existing.Favorite == note.Favorite
+			if same || !note.ModifiedAt.After(existing.ModifiedAt) {
+				result.Skipped++
+				continue
+			}
+
+			edited := existing.newVersion()
+			edited.Name, edited.Notebook = note.Name, note.Notebook
+			edited.Content, edited.Tags = note.Content, note.Tags
+			edited.Pinned, edited.Position, edited.Favorite = note.Pinned, note.Position, note.Favorite
+			if taken(edited.Notebook, edited.Name, edited.ID) {
+				edited = numbered(edited)
+				result.Renamed++
+			}
+			result.Updated++
+			updated[edited.ID] = true
+			notes = append(notes, edited)
+			continue
+		}
+		// Otherwise the ID is only kept if nothing else has it
+		if note.ID == "" || inBatch(note.ID) || s.noteIndex(note.ID) != -1 || s.trashHas(func(n Note) bool { return n.ID == note.ID }) {
+			note.ID = uuid.NewString()
+			note.Version = 1
+		}
+
+		// Trashed notes don't hold on to their names
+		if !note.DeletedAt.IsZero() {
+			result.Imported++
+			notes = append(notes, note)
+			continue
+		}
+
+		if s.nameTaken(note.Notebook, note.Name, "") {
+			switch policy {
+			case SkipExisting:
+				result.Skipped++
+				continue
+			case OverwriteExisting:
+				existing := s.Notes[slices.IndexFunc(s.Notes, func(n Note) bool {
+					return n.Notebook == note.Notebook && n.Name == note.Name
+				})]
+				// A second file for the same note in this import is renamed below instead
+				if !inBatch(existing.ID) {
+					if existing.Content == note.Content && slices.Equal(existing.Tags, note.Tags) {
+						result.Skipped++
+						continue
+					}
+					note = existing.newVersion()
+					note.Content = file.content
+					note.Tags = file.tags
+					result.Overwritten++
+					notes = append(notes, note)
+					continue
+				}
+			}
+		}
+
+		if taken(note.Notebook, note.Name, "") {
+			note = numbered(note)
+			result.Renamed++
+		}
+
+		result.Imported++
+		notes = append(notes, note)
+	}
+
+	// Links to notes the import renamed or moved follow them
+	var moved, added []Note
+	for _, note := range notes {
+		if updated[note.ID] {
+			moved = append(moved, note)
+		} else {
+			added = append(added, note)
+		}
+	}
+
+	if err := s.putAll(append(s.relink(moved...), added...)); err != nil {
+		return ImportResult{}, fmt.Errorf("error saving imported notes: %w", err)
+	}
+
+	return result, nil
+}
+
+// readImportDir reads every importable file in fsys with parse.
+func readImportDir(fsys fs.FS, parse func(p string, data []byte, modTime time.Time) importedNote) ([]importedNote, []error, error) {
+	var files []importedNote
+	var failed []error
+
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", p, err))
+			return nil
+		}
+		// Skip hidden files and folders like .git or .obsidian
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !importable(p) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", p, err))
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", p, err))
+			return nil
+		}
+
+		files = append(files, parse(p, data, info.ModTime()))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading import directory: %w", err)
+	}
+
+	return files, failed, nil
+}
+
+func readImportZip(src string) ([]importedNote, []error, error) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening zip archive: %w", err)
+	}
+	defer zr.Close()
+
+	var files []importedNote
+	var failed []error
+	for _, f := range zr.File {
+		hidden := slices.ContainsFunc(strings.Split(f.Name, "/"), func(part string) bool {
+			return strings.HasPrefix(part, ".")
+		})
+		if f.FileInfo().IsDir() || hidden || !importable(f.Name) {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", f.Name, err))
+			continue
+		}
+
+		files = append(files, parseImportedFile(f.Name, data, f.Modified))
+	}
+
+	return files, failed, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+func readImportFile(src string, info os.FileInfo) ([]importedNote, []error, error) {
+	if !importable(src) {
+		return nil, nil, fmt.Errorf("can't import %s, it's not a directory, zip or Markdown file", src)
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s: %w", src, err)
+	}
+
+	return []importedNote{parseImportedFile(filepath.Base(src), data, info.ModTime())}, nil, nil
+}
+
+func importable(name string) bool {
+	return slices.Contains(importExts, strings.ToLower(path.Ext(name)))
+}
+
+// parseImportedFile turns a file at the slash separated path p into a note. It undoes what
+// the export does to a note, so an exported note comes back the same, ID and all. The
+// notebook is the folder the file is in, unless it's the folder the front matter's
+// notebook was exported to or the file is at the top.
+func parseImportedFile(p string, data []byte, modTime time.Time) importedNote {
+	base := path.Base(p)
+	note := importedNote{
+		notebook: importNotebook(path.Dir(p)),
+		name:     strings.TrimSpace(strings.ReplaceAll(strings.TrimSuffix(base, path.Ext(base)), "_", " ")),
+		created:  modTime,
+		modified: modTime,
+	}
+
+	fields, body, ok := splitFrontMatter(string(data))
+	if !ok {
+		// Exports from before front matter put the tags on a closing line
+		note.content, note.tags = splitTagLine(strings.TrimSpace(string(data)))
+		return note
+	}
+
+	note.content = strings.TrimSpace(body)
+	note.tags = importTags(fields["tags"])
+	if id := fieldValue(fields, "id"); uuid.Validate(id) == nil {
+		note.id = id
+	}
+	if name := fieldValue(fields, "name"); name != "" {
+		note.name = importName(name)
+	}
+	// Unless the file was moved, the front matter has the notebook as it was, not as a folder
+	if notebook := importNotebook(fieldValue(fields, "notebook")); path.Dir(p) == "." || safeNotebookDir(notebook) == path.Dir(p) {
+		note.notebook = notebook
+	}
+	if created, ok := parseImportTime(fields["created"]); ok {
+		note.created = created
+	}
+	if modified, ok := parseImportTime(fields["modified"]); ok {
+		note.modified = modified
+	}
+	if version, err := strconv.Atoi(fieldValue(fields, "version")); err == nil && version > 0 {
+		note.version = version
+	}
+	if position, err := strconv.Atoi(fieldValue(fields, "pinned")); err == nil && position > 0 {
+		note.position = position
+	}
+	note.favorite = fieldValue(fields, "favorite") == "true"
+
+	return note
+}
+
+// fieldValue is the first value of a front matter field, "" when it's missing.
+func fieldValue(fields map[string][]string, key string) string {
+	if len(fields[key]) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(fields[key][0])
+}
+
+// importNotebook turns a slash separated folder path into a notebook. One that doesn't make
+// a valid notebook, like one with .., lands at the top level.
+func importNotebook(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	notebook, _ := CleanNotebook(dir)
+	return notebook
+}
+
+// importName makes a title from another app into a note name, a / would read as a notebook.
+func importName(title string) string {
+	name := strings.TrimSpace(strings.ReplaceAll(title, "/", "-"))
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// importTags makes tags from another app fit, spaces become dashes and tags that still
+// aren't valid are dropped.
+func importTags(tags []string) []string {
+	var valid []string
+	for _, tag := range tags {
+		tag = NormalizeTag(strings.Join(strings.Fields(tag), "-"))
+		if validTag(tag) == nil && !slices.Contains(valid, tag) {
+			valid = append(valid, tag)
+		}
+	}
+	return valid
+}
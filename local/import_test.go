@@ -0,0 +1,169 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImport_RoundTripsExport(t *testing.T) {
+	export := filepath.Join(t.TempDir(), "biji-export.zip")
+
+	source := &Store{}
+	if err := source.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	plan, err := source.AddNoteTo("work/projects", "big plan", "step one\n\nstep two #todo")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := source.AddTags(plan.ID, "work", "todo"); err != nil {
+		t.Fatalf("Failed to tag note: %v", err)
+	}
+	quarter, err := source.AddNote("Q3: \"plans\" #1", "just text")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := source.PinNote(plan.ID, 0); err != nil {
+		t.Fatalf("Failed to pin note: %v", err)
+	}
+	if _, err := source.SetFavorite(quarter.ID, true); err != nil {
+		t.Fatalf("Failed to favorite note: %v", err)
+	}
+
+	if err := source.ExportAllTo(export, FormatMarkdown); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	target := &Store{}
+	if err := target.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	result, err := target.Import(export, SkipExisting)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if result.Imported != 2 || len(result.Failed) != 0 {
+		t.Fatalf("Expected 2 notes imported, got %+v", result)
+	}
+
+	for _, want := range source.Notes {
+		got, err := target.GetNoteFromID(want.ID)
+		if err != nil {
+			t.Errorf("Expected %s imported with its ID: %v", want.Path(), err)
+			continue
+		}
+		if got.Path() != want.Path() || got.Content != want.Content || !slices.Equal(got.Tags, want.Tags) {
+			t.Errorf("Expected %s %q %v, got %s %q %v", want.Path(), want.Content, want.Tags, got.Path(), got.Content, got.Tags)
+		}
+		if got.Pinned != want.Pinned || got.Position != want.Position || got.Favorite != want.Favorite {
+			t.Errorf("Expected %s pinned %v at %d, favorite %v, got %+v", want.Path(), want.Pinned, want.Position, want.Favorite, got)
+		}
+		if !got.CreatedAt.Equal(want.CreatedAt) || !got.ModifiedAt.Equal(want.ModifiedAt) || got.Version != want.Version {
+			t.Errorf("Expected times and version kept, got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestImport_UpdatesNotesByID(t *testing.T) {
+	dir := t.TempDir()
+
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := store.AddNote("draft", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	filePath, err := store.ExportNote(note.ID, dir, FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Failed to export note: %v", err)
+	}
+
+	result, err := store.Import(dir, SkipExisting)
+	if err != nil || result.Skipped != 1 || result.Imported != 0 {
+		t.Fatalf("Expected the unchanged note skipped, got %+v (%v)", result, err)
+	}
+
+	// Edited somewhere else, name included
+	data, _ := os.ReadFile(filePath)
+	edited := strings.Replace(string(data), "name: draft", "name: final", 1)
+	edited = strings.Replace(edited, "\nfirst\n", "\nsecond\n", 1)
+	if err := os.WriteFile(filePath, []byte(edited), 0o644); err != nil {
+		t.Fatalf("Failed to edit export: %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filePath, later, later)
+
+	result, err = store.Import(dir, SkipExisting)
+	if err != nil || result.Updated != 0 || result.Skipped != 1 {
+		t.Fatalf("Expected the front matter time to win over the file's, got %+v (%v)", result, err)
+	}
+
+	edited = regexp.MustCompile(`modified: .*`).ReplaceAllString(edited, "modified: 2999-01-01T00:00:00Z")
+	if err := os.WriteFile(filePath, []byte(edited), 0o644); err != nil {
+		t.Fatalf("Failed to edit export: %v", err)
+	}
+	result, err = store.Import(dir, SkipExisting)
+	if err != nil || result.Updated != 1 || result.Imported != 0 {
+		t.Fatalf("Expected the note updated, got %+v (%v)", result, err)
+	}
+
+	updated, _ := store.GetNoteFromID(note.ID)
+	if updated.Name != "final" || updated.Content != "second" || updated.Version != 2 || len(store.Notes) != 1 {
+		t.Errorf("Expected a new version of the same note, got %+v", updated)
+	}
+}
+
+func TestImport_CollisionPolicies(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "shopping_list.md"), []byte("eggs"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "image.png"), []byte("not a note"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	old := time.Now().Add(-72 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "shopping_list.md"), old, old)
+
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	existing, err := store.AddNote("shopping list", "milk")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	result, err := store.Import(dir, SkipExisting)
+	if err != nil || result.Skipped != 1 || result.Imported != 0 {
+		t.Fatalf("Expected the file skipped, got %+v (%v)", result, err)
+	}
+
+	result, err = store.Import(dir, RenameImported)
+	if err != nil || result.Renamed != 1 || result.Imported != 1 {
+		t.Fatalf("Expected the file renamed, got %+v (%v)", result, err)
+	}
+	id, err := store.FindNoteID("shopping list (2)")
+	if err != nil {
+		t.Fatalf("Expected the renamed note: %v", err)
+	}
+	renamed, _ := store.GetNoteFromID(id)
+	if renamed.Content != "eggs" || !renamed.CreatedAt.Equal(old) {
+		t.Errorf("Expected content and file time kept, got %+v", renamed)
+	}
+
+	result, err = store.Import(dir, OverwriteExisting)
+	if err != nil || result.Overwritten != 1 {
+		t.Fatalf("Expected the note overwritten, got %+v (%v)", result, err)
+	}
+	overwritten, _ := store.GetNoteFromID(existing.ID)
+	if overwritten.Content != "eggs" || overwritten.Version != 2 {
+		t.Errorf("Expected a new version with the file content, got %+v", overwritten)
+	}
+}
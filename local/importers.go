@@ -0,0 +1,359 @@
+package local
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// readObsidian reads an Obsidian vault, a folder of Markdown files with optional front
+// matter. The .obsidian settings and the .trash folder are skipped like any hidden folder.
+func readObsidian(src string) ([]importedNote, []error, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening %s: %w", src, err)
+	}
+	if !info.IsDir() {
+		return nil, nil, fmt.Errorf("can't import %s, an Obsidian vault is a folder", src)
+	}
+
+	return readImportDir(os.DirFS(src), parseObsidianNote)
+}
+
+func parseObsidianNote(p string, data []byte, modTime time.Time) importedNote {
+	fields, body, _ := splitFrontMatter(string(data))
+
+	// Tags are a list, or in older vaults one string split by commas or spaces
+	var tags []string
+	for _, key := range []string{"tags", "tag"} {
+		for _, value := range fields[key] {
+			for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+				tags = append(tags, unquote(tag))
+			}
+		}
+	}
+
+	created := modTime
+	for _, key := range []string{"created", "date"} {
+		if t, ok := parseImportTime(fields[key]); ok {
+			created = t
+			break
+		}
+	}
+
+	base := path.Base(p)
+	return importedNote{
+		notebook: importNotebook(path.Dir(p)),
+		name:     strings.TrimSuffix(base, path.Ext(base)),
+		content:  obsidianLinks(strings.TrimSpace(body)),
+		tags:     importTags(tags),
+		created:  created,
+		modified: modTime,
+	}
+}
+
+// obsidianLinks drops what biji doesn't know from Obsidian's [[links]]: a .md extension and
+// #heading or #^block parts. A link that had a heading keeps it as the shown text.
+func obsidianLinks(content string) string {
+	for _, link := range slices.Backward(ParseLinks(content)) {
+		// ![[embeds]] are attachments, not notes
+		if link.Start > 0 && content[link.Start-1] == '!' {
+			continue
+		}
+
+		target, heading, _ := strings.Cut(link.Target, "#")
+		target = strings.TrimSuffix(strings.TrimSpace(target), ".md")
+		if target == "" || target == link.Target {
+			continue
+		}
+
+		alias := link.Alias
+		if alias == "" && heading != "" {
+			alias = link.Target
+		}
+		content = rewriteLink(content, Link{Alias: alias, Start: link.Start, End: link.End}, Note{Name: target}, false)
+	}
+
+	return content
+}
+
+// parseImportTime reads a date from front matter, with or without a time.
+func parseImportTime(values []string) (time.Time, bool) {
+	if len(values) == 0 {
+		return time.Time{}, false
+	}
+
+	if t, err := time.Parse(time.RFC3339, values[0]); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, values[0], time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Joplin item types, the type_ property of every entry in a JEX archive
+const (
+	joplinNote    = "1"
+	joplinFolder  = "2"
+	joplinTag     = "5"
+	joplinNoteTag = "6"
+)
+
+// joplinItem is one entry of a Joplin JEX archive. A JEX is a tar of .md files, each
+// a title line, a blank line, the body and then a block of key: value properties.
+type joplinItem struct {
+	title string
+	body  string
+	props map[string]string
+}
+
+// joplinLinkPattern matches Markdown links to other Joplin items, [text](:/id).
+var joplinLinkPattern = regexp.MustCompile(`\[([^\[\]\n]*)\]\(:/([0-9a-f]{32})\)`)
+
+// readJoplin reads a Joplin .jex export. Folders become notebooks, tags are attached
+// through the note_tag entries, and links between notes become [[links]].
+func readJoplin(src string) ([]importedNote, []error, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening %s: %w", src, err)
+	}
+	defer f.Close()
+
+	var failed []error
+	var order []string
+	items := make(map[string]joplinItem)
+
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading Joplin archive: %w", err)
+		}
+		// Attachments sit in resources/, the items at the top
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || path.Dir(name) != "." || path.Ext(name) != ".md" {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		item := parseJoplinItem(string(data))
+		id := item.props["id"]
+		if id == "" {
+			failed = append(failed, fmt.Errorf("%s: no id, not a Joplin item", name))
+			continue
+		}
+
+		items[id] = item
+		order = append(order, id)
+	}
+
+	notebook := func(id string) string {
+		var parts []string
+		seen := make(map[string]bool)
+		for id != "" && !seen[id] {
+			seen[id] = true
+			folder, ok := items[id]
+			if !ok || folder.props["type_"] != joplinFolder {
+				break
+			}
+			parts = append([]string{importName(folder.title)}, parts...)
+			id = folder.props["parent_id"]
+		}
+		return importNotebook(strings.Join(parts, "/"))
+	}
+
+	tags := make(map[string][]string)
+	for _, item := range items {
+		if item.props["type_"] != joplinNoteTag {
+			continue
+		}
+		if tag, ok := items[item.props["tag_id"]]; ok && tag.props["type_"] == joplinTag {
+			tags[item.props["note_id"]] = append(tags[item.props["note_id"]], tag.title)
+		}
+	}
+
+	// Where every note ends up, for the links between them
+	targets := make(map[string]Note)
+	for _, id := range order {
+		if item := items[id]; item.props["type_"] == joplinNote {
+			targets[id] = Note{Notebook: notebook(item.props["parent_id"]), Name: importName(item.title)}
+		}
+	}
+
+	var notes []importedNote
+	for _, id := range order {
+		item := items[id]
+		// Conflict copies are Joplin's own sync leftovers
+		if item.props["type_"] != joplinNote || item.props["is_conflict"] == "1" {
+			continue
+		}
+
+		notes = append(notes, importedNote{
+			notebook: targets[id].Notebook,
+			name:     targets[id].Name,
+			content:  joplinLinks(strings.TrimSpace(item.body), targets),
+			tags:     importTags(tags[id]),
+			created:  cmp.Or(joplinTime(item.props["user_created_time"]), joplinTime(item.props["created_time"])),
+			modified: cmp.Or(joplinTime(item.props["user_updated_time"]), joplinTime(item.props["updated_time"])),
+			deleted:  joplinTime(item.props["deleted_time"]),
+		})
+	}
+
+	return notes, failed, nil
+}
+
+func parseJoplinItem(data string) joplinItem {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	item := joplinItem{props: make(map[string]string)}
+
+	// The properties are the last block of lines, read it from the bottom up
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	for ; end > 0; end-- {
+		key, value, ok := strings.Cut(lines[end-1], ":")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			break
+		}
+		item.props[key] = strings.TrimSpace(value)
+	}
+
+	if end > 0 {
+		item.title = lines[0]
+		item.body = strings.Join(lines[1:end], "\n")
+	}
+
+	return item
+}
+
+// joplinTime reads a time property. Times are ISO 8601, though some are milliseconds, 0
+// meaning unset.
+func joplinTime(value string) time.Time {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if ms == 0 {
+			return time.Time{}
+		}
+		return time.UnixMilli(ms)
+	}
+
+	t, _ := time.Parse(time.RFC3339Nano, value)
+	return t
+}
+
+// joplinLinks turns links to other notes into [[links]] by path. Links to attachments
+// and anything else that isn't a note are left alone.
+func joplinLinks(content string, targets map[string]Note) string {
+	return joplinLinkPattern.ReplaceAllStringFunc(content, func(match string) string {
+		m := joplinLinkPattern.FindStringSubmatch(match)
+		target, ok := targets[m[2]]
+		if !ok {
+			return match
+		}
+
+		alias := m[1]
+		if alias == target.Name {
+			alias = ""
+		}
+		return rewriteLink(match, Link{Alias: alias, End: len(match)}, target, true)
+	})
+}
+
+// simplenoteExport is the notes.json in a Simplenote export.
+type simplenoteExport struct {
+	ActiveNotes  []simplenoteNote `json:"activeNotes"`
+	TrashedNotes []simplenoteNote `json:"trashedNotes"`
+}
+
+type simplenoteNote struct {
+	Content      string    `json:"content"`
+	CreationDate time.Time `json:"creationDate"`
+	LastModified time.Time `json:"lastModified"`
+	Tags         []string  `json:"tags"`
+}
+
+// readSimplenote reads a Simplenote export, the notes.json on its own or the zip it comes
+// in. Simplenote notes have no names, the first line of a note becomes its name.
+func readSimplenote(src string) ([]importedNote, []error, error) {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(src), ".zip") {
+		data, err = readZipNotesJSON(src)
+	} else {
+		data, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading Simplenote export: %w", err)
+	}
+
+	var export simplenoteExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshalling Simplenote export: %w", err)
+	}
+
+	var notes []importedNote
+	for _, note := range export.ActiveNotes {
+		notes = append(notes, note.imported())
+	}
+	for _, note := range export.TrashedNotes {
+		trashed := note.imported()
+		trashed.deleted = cmp.Or(note.LastModified, time.Now())
+		notes = append(notes, trashed)
+	}
+
+	return notes, nil, nil
+}
+
+func (n simplenoteNote) imported() importedNote {
+	content := strings.TrimSpace(strings.ReplaceAll(n.Content, "\r\n", "\n"))
+	title, body, _ := strings.Cut(content, "\n")
+
+	return importedNote{
+		// Markdown notes often start with a # heading
+		name:     importName(strings.TrimLeft(title, "# ")),
+		content:  strings.TrimSpace(body),
+		tags:     importTags(n.Tags),
+		created:  n.CreationDate,
+		modified: n.LastModified,
+	}
+}
+
+func readZipNotesJSON(src string) ([]byte, error) {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if path.Base(f.Name) == "notes.json" {
+			return readZipFile(f)
+		}
+	}
+
+	return nil, fmt.Errorf("no notes.json in %s", src)
+}
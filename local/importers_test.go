@@ -0,0 +1,114 @@
+package local
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func importFixture(t *testing.T, format, fixture string) (*Store, ImportResult) {
+	t.Helper()
+
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	result, err := store.ImportFrom(format, filepath.Join("testdata", "import", fixture), SkipExisting)
+	if err != nil {
+		t.Fatalf("Failed to import %s: %v", fixture, err)
+	}
+	if len(result.Failed) != 0 {
+		t.Fatalf("Expected no failed entries, got %v", result.Failed)
+	}
+
+	return store, result
+}
+
+func findImported(t *testing.T, store *Store, path string) Note {
+	t.Helper()
+
+	id, err := store.FindNoteID(path)
+	if err != nil {
+		t.Fatalf("Expected %s imported: %v", path, err)
+	}
+	note, _ := store.GetNoteFromID(id)
+	return note
+}
+
+func TestImportFrom_Obsidian(t *testing.T) {
+	store, result := importFixture(t, "obsidian", "obsidian")
+	if result.Imported != 3 {
+		t.Fatalf("Expected 3 notes, the trash and settings skipped, got %+v", result)
+	}
+
+	plan := findImported(t, store, "Projects/Launch Plan")
+	if !slices.Equal(plan.Tags, []string{"work", "project/launch"}) {
+		t.Errorf("Expected front matter tags, got %v", plan.Tags)
+	}
+	if want := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC); !plan.CreatedAt.Equal(want) {
+		t.Errorf("Expected created %v from front matter, got %v", want, plan.CreatedAt)
+	}
+	wantContent := "# Launch plan\n\nShip it in April. See [[Inbox|the inbox]] and [[Inbox]].\n\n![[diagram.png]]"
+	if plan.Content != wantContent {
+		t.Errorf("Expected %q, got %q", wantContent, plan.Content)
+	}
+
+	inbox := findImported(t, store, "Inbox")
+	if !slices.Equal(inbox.AllTags(), []string{"errands", "home", "shopping"}) {
+		t.Errorf("Expected front matter and inline tags, got %v", inbox.AllTags())
+	}
+
+	scratch := findImported(t, store, "Scratch")
+	if links, _ := store.Links(scratch.ID); len(links) != 1 || links[0].Target != "Launch Plan" || links[0].Alias != "Launch Plan#^b1" {
+		t.Errorf("Expected the block link pointed at the note, got %+v", links)
+	}
+	if broken := store.BrokenLinks(); len(broken) != 1 || broken[0].Link.Target != "diagram.png" {
+		t.Errorf("Expected only the embed unresolved, got %+v", broken)
+	}
+}
+
+func TestImportFrom_Joplin(t *testing.T) {
+	store, result := importFixture(t, "joplin", "joplin.jex")
+	if result.Imported != 2 {
+		t.Fatalf("Expected 2 notes, the conflict copy skipped, got %+v", result)
+	}
+
+	standup := findImported(t, store, "Work/Meetings/Standup notes")
+	if !slices.Equal(standup.Tags, []string{"team-sync"}) {
+		t.Errorf("Expected the Joplin tag, got %v", standup.Tags)
+	}
+	if want := time.Date(2023, 5, 3, 10, 15, 0, 0, time.UTC); !standup.ModifiedAt.Equal(want) {
+		t.Errorf("Expected modified %v, got %v", want, standup.ModifiedAt)
+	}
+	wantContent := "Talked about the release.\nFollow up in [[Work/Q3-Q4 goals|Q3/Q4 goals]], screenshot below.\n\n![shot](:/0d000000000000000000000000000001)"
+	if standup.Content != wantContent {
+		t.Errorf("Expected %q, got %q", wantContent, standup.Content)
+	}
+
+	goals := findImported(t, store, "Work/Q3-Q4 goals")
+	if backlinks, _ := store.Backlinks(goals.ID); len(backlinks) != 1 || backlinks[0].ID != standup.ID {
+		t.Errorf("Expected the note link to become a backlink, got %+v", backlinks)
+	}
+}
+
+func TestImportFrom_Simplenote(t *testing.T) {
+	store, result := importFixture(t, "simplenote", filepath.Join("simplenote", "notes.json"))
+	if result.Imported != 4 || result.Renamed != 1 {
+		t.Fatalf("Expected 4 notes with one renamed, got %+v", result)
+	}
+
+	groceries := findImported(t, store, "Groceries")
+	if groceries.Content != "Eggs\nBread" || !slices.Equal(groceries.Tags, []string{"home", "to-buy"}) {
+		t.Errorf("Expected the first line as name and tags kept, got %+v", groceries)
+	}
+	if party := findImported(t, store, "Groceries (2)"); party.Content != "For the party" {
+		t.Errorf("Expected the second Groceries renamed, got %+v", party)
+	}
+	findImported(t, store, "Books-films to check out")
+
+	if len(store.Trash) != 1 || store.Trash[0].Name != "Old idea" {
+		t.Errorf("Expected the trashed note in the trash, got %+v", store.Trash)
+	}
+}
@@ -0,0 +1,274 @@
+package local
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// linkPattern matches [[Target]] and [[Target|shown text]].
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]*))?\]\]`)
+
+// Link is a [[wiki link]] in a note's content. Target is a note name or path like
+// work/todo, Alias the text after a |, Start and End the byte offsets of the whole link.
+type Link struct {
+	Target     string
+	Alias      string
+	Start, End int
+}
+
+// BrokenLink is a link that doesn't lead to exactly one note.
+type BrokenLink struct {
+	Note Note
+	Link Link
+}
+
+// ParseLinks returns the [[links]] in content in the order they appear.
+func ParseLinks(content string) []Link {
+	var links []Link
+	for _, m := range linkPattern.FindAllStringSubmatchIndex(content, -1) {
+		link := Link{
+			Target: strings.TrimSpace(content[m[2]:m[3]]),
+			Start:  m[0],
+			End:    m[1],
+		}
+		if m[4] != -1 {
+			link.Alias = content[m[4]:m[5]]
+		}
+		if link.Target != "" {
+			links = append(links, link)
+		}
+	}
+
+	return links
+}
+
+// linkGraph keeps the outgoing links of every live note and, the other way around, which
+// notes link to a target, so backlinks don't need every note reparsed.
+type linkGraph struct {
+	out map[string][]Link          // note ID -> links in its content
+	in  map[string]map[string]bool // link target -> IDs of the notes linking to it
+	at  map[string]time.Time       // note ID -> ModifiedAt when it was parsed
+}
+
+func newLinkGraph() *linkGraph {
+	return &linkGraph{
+		out: make(map[string][]Link),
+		in:  make(map[string]map[string]bool),
+		at:  make(map[string]time.Time),
+	}
+}
+
+func (g *linkGraph) add(note Note) {
+	g.remove(note.ID)
+
+	links := ParseLinks(note.Content)
+	for _, link := range links {
+		if g.in[link.Target] == nil {
+			g.in[link.Target] = make(map[string]bool)
+		}
+		g.in[link.Target][note.ID] = true
+	}
+	g.out[note.ID] = links
+	g.at[note.ID] = note.ModifiedAt
+}
+
+func (g *linkGraph) remove(id string) {
+	for _, link := range g.out[id] {
+		delete(g.in[link.Target], id)
+		if len(g.in[link.Target]) == 0 {
+			delete(g.in, link.Target)
+		}
+	}
+	delete(g.out, id)
+	delete(g.at, id)
+}
+
+// refresh works like searchIndex.refresh, only changed notes are parsed again.
+func (g *linkGraph) refresh(notes []Note) {
+	live := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		live[note.ID] = true
+		if at, ok := g.at[note.ID]; !ok || !at.Equal(note.ModifiedAt) {
+			g.add(note)
+		}
+	}
+
+	for id := range g.out {
+		if !live[id] {
+			g.remove(id)
+		}
+	}
+}
+
+// resolveLink finds the note a link target points at, the same way FindNoteID finds a name.
+func (s *Store) resolveLink(target string) (string, bool) {
+	id, err := s.findNoteID(target)
+	return id, err == nil
+}
+
+// Links returns the links in a note's content.
+func (s *Store) Links(id string) ([]Link, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.noteIndex(id) == -1 {
+		return nil, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+
+	return slices.Clone(s.links.out[id]), nil
+}
+
+// Backlinks returns the notes that link to the note with id.
+func (s *Store) Backlinks(id string) ([]Note, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	i := s.noteIndex(id)
+	if i == -1 {
+		return nil, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+
+	var notes []Note
+	for source := range s.inboundLinks(s.Notes[i]) {
+		if j := s.noteIndex(source); j != -1 {
+			notes = append(notes, s.Notes[j])
+		}
+	}
+	// Keep the usual newest first order
+	slices.SortFunc(notes, func(a, b Note) int { return b.ModifiedAt.Compare(a.ModifiedAt) })
+
+	return notes, nil
+}
+
+// inboundLinks maps the notes linking to note to the links that do. The caller must hold a lock.
+func (s *Store) inboundLinks(note Note) map[string][]Link {
+	inbound := make(map[string][]Link)
+	for _, target := range []string{note.Path(), note.Name} {
+		for source := range s.links.in[target] {
+			for _, link := range s.links.out[source] {
+				if link.Target != target {
+					continue
+				}
+				if id, ok := s.resolveLink(link.Target); ok && id == note.ID && !slices.Contains(inbound[source], link) {
+					inbound[source] = append(inbound[source], link)
+				}
+			}
+		}
+	}
+
+	return inbound
+}
+
+// BrokenLinks reports every link that leads nowhere, or to more than one note.
+func (s *Store) BrokenLinks() []BrokenLink {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var broken []BrokenLink
+	for _, note := range s.Notes {
+		for _, link := range s.links.out[note.ID] {
+			if _, ok := s.resolveLink(link.Target); !ok {
+				broken = append(broken, BrokenLink{Note: note, Link: link})
+			}
+		}
+	}
+
+	return broken
+}
+
+// relink points the links at notes that are being renamed or moved at their new name
+// or path. moved holds the new versions of those notes, the old ones still have to be in
+// Notes. It returns moved, with their own links rewritten, plus new versions of every
+// other note whose links changed. The caller must hold the write lock.
+func (s *Store) relink(moved ...Note) []Note {
+	after := make(map[string]Note, len(moved))
+	for _, note := range moved {
+		after[note.ID] = note
+	}
+
+	// Which links in which note point at which moved note, worked out before anything moves
+	type edit struct {
+		link   Link
+		target Note
+	}
+	edits := make(map[string][]edit)
+	for _, note := range moved {
+		i := s.noteIndex(note.ID)
+		if i == -1 {
+			continue
+		}
+		for source, links := range s.inboundLinks(s.Notes[i]) {
+			for _, link := range links {
+				edits[source] = append(edits[source], edit{link, note})
+			}
+		}
+	}
+
+	// A bare name only stays bare if no other note will share it
+	shared := func(target Note) bool {
+		for _, note := range s.Notes {
+			if moved, ok := after[note.ID]; ok {
+				note = moved
+			}
+			if note.ID != target.ID && note.Name == target.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var changed []Note
+	for source, sourceEdits := range edits {
+		note, isMoved := after[source]
+		if !isMoved {
+			note = s.Notes[s.noteIndex(source)]
+		}
+
+		// Work from the end so the earlier offsets stay valid
+		slices.SortFunc(sourceEdits, func(a, b edit) int { return b.link.Start - a.link.Start })
+		content := note.Content
+		for _, e := range sourceEdits {
+			qualify := strings.Contains(e.link.Target, "/") || shared(e.target)
+			content = rewriteLink(content, e.link, e.target, qualify)
+		}
+
+		switch {
+		case isMoved:
+			note.Content = content
+			after[source] = note
+		case content != note.Content:
+			// Links by a name that still works are left alone, no need for a new version then
+			updated := note.newVersion()
+			updated.Content = content
+			changed = append(changed, updated)
+		}
+	}
+
+	for _, note := range moved {
+		changed = append(changed, after[note.ID])
+	}
+
+	return changed
+}
+
+// rewriteLink replaces one link with a link to target, by path when qualify is set.
+func rewriteLink(content string, link Link, target Note, qualify bool) string {
+	if link.End > len(content) {
+		return content
+	}
+
+	name := target.Name
+	if qualify {
+		name = target.Path()
+	}
+
+	text := "[[" + name + "]]"
+	if link.Alias != "" {
+		text = "[[" + name + "|" + link.Alias + "]]"
+	}
+
+	return content[:link.Start] + text + content[link.End:]
+}
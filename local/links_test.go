@@ -0,0 +1,73 @@
+package local
+
+import (
+	"testing"
+)
+
+func TestParseLinks(t *testing.T) {
+	links := ParseLinks("see [[Plan]] and [[work/todo|the list]], not [[]] or [single]")
+	if len(links) != 2 {
+		t.Fatalf("Expected 2 links, got %v", links)
+	}
+	if links[0].Target != "Plan" || links[1].Target != "work/todo" || links[1].Alias != "the list" {
+		t.Errorf("Unexpected links: %+v", links)
+	}
+}
+
+func TestLinks_BacklinksAndRename(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	plan, err := store.AddNote("plan", "the plan, see [[plan]] for itself")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	todo, err := store.AddNoteTo("work", "todo", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	journal, err := store.AddNote("journal", "followed [[plan|the plan]] and [[work/todo]], skipped [[missing]]")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	backlinks, err := store.Backlinks(plan.ID)
+	if err != nil {
+		t.Fatalf("Failed to get backlinks: %v", err)
+	}
+	if len(backlinks) != 2 {
+		t.Errorf("Expected plan linked from itself and journal, got %d", len(backlinks))
+	}
+
+	broken := store.BrokenLinks()
+	if len(broken) != 1 || broken[0].Link.Target != "missing" || broken[0].Note.ID != journal.ID {
+		t.Errorf("Expected one broken link in journal, got %v", broken)
+	}
+
+	if _, err := store.UpdateNoteName(plan.ID, "roadmap"); err != nil {
+		t.Fatalf("Failed to rename note: %v", err)
+	}
+	updated, _ := store.GetNoteFromID(journal.ID)
+	want := "followed [[roadmap|the plan]] and [[work/todo]], skipped [[missing]]"
+	if updated.Content != want {
+		t.Errorf("Expected inbound link rewritten to\n%s\ngot\n%s", want, updated.Content)
+	}
+	renamed, _ := store.GetNoteFromID(plan.ID)
+	if renamed.Content != "the plan, see [[roadmap]] for itself" {
+		t.Errorf("Expected the self link rewritten, got %s", renamed.Content)
+	}
+
+	if _, err := store.MoveNote(todo.ID, "archive"); err != nil {
+		t.Fatalf("Failed to move note: %v", err)
+	}
+	updated, _ = store.GetNoteFromID(journal.ID)
+	want = "followed [[roadmap|the plan]] and [[archive/todo]], skipped [[missing]]"
+	if updated.Content != want {
+		t.Errorf("Expected path link rewritten to\n%s\ngot\n%s", want, updated.Content)
+	}
+	if backlinks, _ := store.Backlinks(todo.ID); len(backlinks) != 1 {
+		t.Errorf("Expected the moved note to keep its backlink, got %d", len(backlinks))
+	}
+}
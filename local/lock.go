@@ -0,0 +1,66 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrConflict is returned when a note was changed by another biji process after this
+// store last read it. The store has the other process's version loaded by then, so the
+// caller can look at it and try again.
+var ErrConflict = errors.New("note was changed by another biji process")
+
+// lockAndReload takes the cross process lock on the data file and reloads the notes
+// so a write starts from what is on disk, not from this process's possibly stale copy.
+// When the backend says nothing changed on disk since Notes was loaded, the copy is
+// current and isn't reloaded.
+// The caller must hold the write lock and call the returned unlock when done writing.
+func (s *Store) lockAndReload() (func(), error) {
+	f, err := os.OpenFile(s.dataFile+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file: %w", err)
+	}
+
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error locking data file: %w", err)
+	}
+
+	unlock := func() {
+		unlockFile(f)
+		f.Close()
+	}
+
+	// Nothing to reload when no other process wrote since this one last read or wrote,
+	// unless GetNotes read the file without reloading Notes
+	if !s.stale {
+		changed, err := s.backend.Changed()
+		if err == nil && !changed {
+			return unlock, nil
+		}
+	}
+
+	if err := s.load(); err != nil {
+		unlock()
+		return nil, fmt.Errorf("error reloading notes: %w", err)
+	}
+
+	return unlock, nil
+}
+
+// checkUnchanged compares the note this process last saw with the reloaded one.
+// It returns ErrConflict when another process modified or deleted it in between.
+func (s *Store) checkUnchanged(seen Note) error {
+	for _, note := range s.Notes {
+		if note.ID != seen.ID {
+			continue
+		}
+		if !note.ModifiedAt.Equal(seen.ModifiedAt) {
+			return fmt.Errorf("%w: %s", ErrConflict, seen.Name)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s was deleted", ErrConflict, seen.Name)
+}
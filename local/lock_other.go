@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package local
+
+import "os"
+
+// Platforms without file locking only get the in process mutex.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
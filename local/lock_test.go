@@ -0,0 +1,268 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const writerNotes = 15
+
+// TestWriterProcess is the child side of TestCompetingProcesses, it only runs when
+// started by that test with BIJI_WRITER_DIR set.
+func TestWriterProcess(t *testing.T) {
+	dir := os.Getenv("BIJI_WRITER_DIR")
+	if dir == "" {
+		t.Skip("only runs as a child of TestCompetingProcesses")
+	}
+	writer := os.Getenv("BIJI_WRITER_ID")
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	for i := range writerNotes {
+		if _, err := store.AddNote(fmt.Sprintf("writer-%s-%d", writer, i), "content"); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+	}
+}
+
+func TestCompetingProcesses(t *testing.T) {
+	dir := t.TempDir()
+
+	// Every writer opens the store before any of them writes, so each one
+	// starts from the same stale copy of the notes
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	const writers = 4
+	cmds := make([]*exec.Cmd, writers)
+	for i := range cmds {
+		cmds[i] = exec.Command(os.Args[0], "-test.run=^TestWriterProcess$")
+		cmds[i].Env = append(os.Environ(), "BIJI_WRITER_DIR="+dir, "BIJI_WRITER_ID="+strconv.Itoa(i))
+		if err := cmds[i].Start(); err != nil {
+			t.Fatalf("Failed to start writer: %v", err)
+		}
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("Writer failed: %v", err)
+		}
+	}
+
+	notes, err := store.GetNotes()
+	if err != nil {
+		t.Fatalf("Failed to load notes: %v", err)
+	}
+	if len(notes) != writers*writerNotes {
+		t.Errorf("Expected %d notes from all writers, got %d", writers*writerNotes, len(notes))
+	}
+}
+
+func TestCompetingStores(t *testing.T) {
+	dir := t.TempDir()
+
+	first := &Store{}
+	second := &Store{}
+	if err := first.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	if err := second.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i, store := range []*Store{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				if _, err := store.AddNote(fmt.Sprintf("store-%d-%d", i, j), ""); err != nil {
+					t.Errorf("Failed to add note: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	notes, err := first.GetNotes()
+	if err != nil {
+		t.Fatalf("Failed to load notes: %v", err)
+	}
+	if len(notes) != 20 {
+		t.Errorf("Expected 20 notes from both stores, got %d", len(notes))
+	}
+}
+
+func TestStaleUpdate_ReturnsConflict(t *testing.T) {
+	dir := t.TempDir()
+
+	first := &Store{}
+	if err := first.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := first.AddNote("shared", "original")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	second := &Store{}
+	if err := second.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	if _, err := first.UpdateNoteContent(note.ID, "from first"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+
+	// second still holds the original and would overwrite the first store's edit
+	_, err = second.UpdateNoteContent(note.ID, "from second")
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("Expected ErrConflict for a stale update, got %v", err)
+	}
+
+	// After the conflict second has the fresh copy and can try again
+	if _, err := second.UpdateNoteContent(note.ID, "from second"); err != nil {
+		t.Errorf("Expected retry after reload to succeed, got %v", err)
+	}
+}
+
+func TestAppendNoteContent_FromCompetingStores(t *testing.T) {
+	dir := t.TempDir()
+
+	first := &Store{}
+	if err := first.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := first.AddNote("log", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	second := &Store{}
+	if err := second.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	// Appends never conflict, even when a store's copy is stale
+	for i, store := range []*Store{first, second, first} {
+		if _, err := store.AppendNoteContent(note.ID, fmt.Sprintf("line %d\n", i)); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	appended, err := first.GetNoteFromID(note.ID)
+	if err != nil {
+		t.Fatalf("Failed to get note: %v", err)
+	}
+	if appended.Content != "line 0\nline 1\nline 2" {
+		t.Errorf("Expected every line appended in order, got %q", appended.Content)
+	}
+}
+
+// countingBackend counts the loads that reach the backend.
+type countingBackend struct {
+	Backend
+	loads int
+}
+
+func (b *countingBackend) Load() ([]Note, error) {
+	b.loads++
+	return b.Backend.Load()
+}
+
+func TestLockAndReload_OnlyWhenChanged(t *testing.T) {
+	for _, storage := range []string{"json", "sqlite"} {
+		t.Run(storage, func(t *testing.T) {
+			t.Setenv("BIJI_STORAGE", storage)
+			dir := t.TempDir()
+
+			first := &Store{}
+			if err := first.InitAt(dir); err != nil {
+				t.Fatalf("Failed to create test store: %v", err)
+			}
+			defer first.Close()
+			second := &Store{}
+			if err := second.InitAt(dir); err != nil {
+				t.Fatalf("Failed to create test store: %v", err)
+			}
+			defer second.Close()
+
+			counting := &countingBackend{Backend: first.backend}
+			first.backend = counting
+
+			note, err := first.AddNote("mine", "one")
+			if err != nil {
+				t.Fatalf("Failed to add note: %v", err)
+			}
+			for _, content := range []string{"two", "three"} {
+				if _, err := first.UpdateNoteContent(note.ID, content); err != nil {
+					t.Fatalf("Failed to update note: %v", err)
+				}
+			}
+			if counting.loads != 0 {
+				t.Errorf("Expected no reloads while only this store writes, got %d", counting.loads)
+			}
+
+			if _, err := second.AddNote("theirs", ""); err != nil {
+				t.Fatalf("Failed to add note: %v", err)
+			}
+			if _, err := first.UpdateNoteContent(note.ID, "four"); err != nil {
+				t.Fatalf("Failed to update note: %v", err)
+			}
+			if counting.loads != 1 || len(first.Notes) != 2 {
+				t.Errorf("Expected one reload picking up the other store's note, got %d with %d notes", counting.loads, len(first.Notes))
+			}
+		})
+	}
+}
+
+func TestGetNotes_StaleUpdateReturnsConflict(t *testing.T) {
+	for _, storage := range []string{"json", "sqlite"} {
+		t.Run(storage, func(t *testing.T) {
+			t.Setenv("BIJI_STORAGE", storage)
+			dir := t.TempDir()
+
+			first := &Store{}
+			if err := first.InitAt(dir); err != nil {
+				t.Fatalf("Failed to create test store: %v", err)
+			}
+			defer first.Close()
+			note, err := first.AddNote("shared", "original")
+			if err != nil {
+				t.Fatalf("Failed to add note: %v", err)
+			}
+			second := &Store{}
+			if err := second.InitAt(dir); err != nil {
+				t.Fatalf("Failed to create test store: %v", err)
+			}
+			defer second.Close()
+
+			if _, err := second.UpdateNoteContent(note.ID, "from second"); err != nil {
+				t.Fatalf("Failed to update note: %v", err)
+			}
+
+			// Reading the notes doesn't make first's own copy current
+			if _, err := first.GetNotes(); err != nil {
+				t.Fatalf("Failed to get notes: %v", err)
+			}
+			if _, err := first.UpdateNoteContent(note.ID, "from first"); !errors.Is(err, ErrConflict) {
+				t.Fatalf("Expected ErrConflict for a stale update, got %v", err)
+			}
+
+			updated, err := first.GetNoteFromID(note.ID)
+			if err != nil || updated.Content != "from second" {
+				t.Errorf("Expected the second store's edit kept, got %q (%v)", updated.Content, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package local
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// notebooksFile keeps the notebooks created with CreateNotebook. Notebooks that hold notes
+// exist anyway, the file is what keeps an empty one around.
+const notebooksFile = "notebooks.json"
+
+// Path returns the note's name prefixed with its notebook, like work/projects/todo.
+func (n Note) Path() string {
+	if n.Notebook == "" {
+		return n.Name
+	}
+	return n.Notebook + "/" + n.Name
+}
+
+// CleanNotebook normalizes a notebook path, trimming spaces and slashes around every part.
+// An empty path, or /, is the top level.
+func CleanNotebook(notebook string) (string, error) {
+	var parts []string
+	for part := range strings.SplitSeq(notebook, "/") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "":
+			continue
+		case ".", "..":
+			return "", fmt.Errorf("notebook: %s, can't contain . or ..", notebook)
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, "/"), nil
+}
+
+// CheckName returns an error for a note name with a /, work/todo would read as the note
+// todo in the notebook work.
+func CheckName(name string) error {
+	if strings.Contains(name, "/") {
+		return fmt.Errorf("name: %s, can't contain /, put the note in a notebook instead", name)
+	}
+	return nil
+}
+
+// inNotebook reports whether notebook is parent or one of the notebooks nested in it.
+func inNotebook(notebook, parent string) bool {
+	return parent == "" || notebook == parent || strings.HasPrefix(notebook, parent+"/")
+}
+
+// nameTaken reports whether another live note in notebook already has name.
+func (s *Store) nameTaken(notebook, name, id string) bool {
+	for _, note := range s.notesNamed(name) {
+		if note.Notebook == notebook && note.ID != id {
+			return true
+		}
+	}
+	return false
+}
+
+// Notebooks returns every notebook, the created ones and the ones notes are in, along
+// with the notebooks above them, sorted so a notebook comes right before its children.
+func (s *Store) Notebooks() ([]string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	created, err := s.loadNotebooks()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	add := func(notebook string) {
+		for notebook != "" && !seen[notebook] {
+			seen[notebook] = true
+			notebook, _, _ = cutLast(notebook)
+		}
+	}
+	for _, notebook := range created {
+		add(notebook)
+	}
+	for _, note := range s.Notes {
+		add(note.Notebook)
+	}
+
+	notebooks := make([]string, 0, len(seen))
+	for notebook := range seen {
+		notebooks = append(notebooks, notebook)
+	}
+	// Compare by parts so work/a sorts before work-b/x, children follow their parent
+	slices.SortFunc(notebooks, func(a, b string) int {
+		return slices.Compare(strings.Split(a, "/"), strings.Split(b, "/"))
+	})
+
+	return notebooks, nil
+}
+
+// NotesIn returns the notes directly in notebook, or in it and every notebook below
+// it when nested is true.
+func (s *Store) NotesIn(notebook string, nested bool) []Note {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var notes []Note
+	for _, note := range s.Notes {
+		if note.Notebook == notebook || (nested && inNotebook(note.Notebook, notebook)) {
+			notes = append(notes, note)
+		}
+	}
+
+	return notes
+}
+
+// CreateNotebook adds an empty notebook, creating the ones above it too.
+func (s *Store) CreateNotebook(notebook string) (string, error) {
+	notebook, err := CleanNotebook(notebook)
+	if err != nil {
+		return "", err
+	}
+	if notebook == "" {
+		return "", fmt.Errorf("notebook name is empty")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	created, err := s.loadNotebooks()
+	if err != nil {
+		return "", err
+	}
+	if slices.Contains(created, notebook) {
+		return notebook, nil
+	}
+
+	return notebook, s.saveNotebooks(append(created, notebook))
+}
+
+// MoveNote puts a note in another notebook, "" being the top level. It fails if the
+// notebook already has a note with the same name. Links by path are updated.
+func (s *Store) MoveNote(id, notebook string) (Note, error) {
+	notebook, err := CleanNotebook(notebook)
+	if err != nil {
+		return Note{}, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
+
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+	if s.Notes[i].Notebook == notebook {
+		return s.Notes[i], nil
+	}
+	if s.nameTaken(notebook, s.Notes[i].Name, id) {
+		return Note{}, fmt.Errorf("name: %s, %w in %s", s.Notes[i].Name, ErrNameTaken, displayNotebook(notebook))
+	}
+
+	updated := s.Notes[i].newVersion()
+	updated.Notebook = notebook
+
+	if err := s.putAll(s.relink(updated)); err != nil {
+		return Note{}, err
+	}
+
+	moved, _ := s.cachedNote(id)
+	return moved, nil
+}
+
+// MoveNotebook moves a notebook, with its notes and the notebooks nested in it, so it
+// ends up at to. Moving work/old to archive/old keeps everything below it in place.
+func (s *Store) MoveNotebook(from, to string) error {
+	from, err := CleanNotebook(from)
+	if err != nil {
+		return err
+	}
+	to, err = CleanNotebook(to)
+	if err != nil {
+		return err
+	}
+	if from == "" {
+		return fmt.Errorf("the top level can't be moved")
+	}
+	if inNotebook(to, from) {
+		return fmt.Errorf("can't move %s into itself", from)
+	}
+
+	rebase := func(notebook string) string {
+		return to + strings.TrimPrefix(notebook, from)
+	}
+	if to == "" {
+		rebase = func(notebook string) string {
+			return strings.TrimPrefix(strings.TrimPrefix(notebook, from), "/")
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var moved []Note
+	for _, note := range s.Notes {
+		if !inNotebook(note.Notebook, from) {
+			continue
+		}
+		updated := note.newVersion()
+		updated.Notebook = rebase(note.Notebook)
+		if s.nameTaken(updated.Notebook, updated.Name, updated.ID) {
+			return fmt.Errorf("name: %s, %w in %s", updated.Name, ErrNameTaken, displayNotebook(updated.Notebook))
+		}
+		moved = append(moved, updated)
+	}
+
+	created, err := s.loadNotebooks()
+	if err != nil {
+		return err
+	}
+	found := len(moved) > 0
+	for i, notebook := range created {
+		if inNotebook(notebook, from) {
+			created[i] = rebase(notebook)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w notebook: %s", ErrNotFound, from)
+	}
+
+	if err := s.saveNotebooks(slices.DeleteFunc(created, func(notebook string) bool { return notebook == "" })); err != nil {
+		return err
+	}
+
+	return s.putAll(s.relink(moved...))
+}
+
+func (s *Store) loadNotebooks() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir(), notebooksFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading notebooks: %w", err)
+	}
+
+	var notebooks []string
+	if err := json.Unmarshal(data, &notebooks); err != nil {
+		return nil, fmt.Errorf("error unmarshalling notebooks: %w", err)
+	}
+
+	return notebooks, nil
+}
+
+func (s *Store) saveNotebooks(notebooks []string) error {
+	slices.Sort(notebooks)
+
+	data, err := json.MarshalIndent(slices.Compact(notebooks), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling notebooks: %w", err)
+	}
+
+	if err := WriteFileAtomic(filepath.Join(s.Dir(), notebooksFile), data, 0o644); err != nil {
+		return fmt.Errorf("error saving notebooks: %w", err)
+	}
+
+	return nil
+}
+
+// cutLast splits the last part off a notebook path.
+func cutLast(notebook string) (parent, name string, found bool) {
+	i := strings.LastIndex(notebook, "/")
+	if i == -1 {
+		return "", notebook, false
+	}
+	return notebook[:i], notebook[i+1:], true
+}
+
+func displayNotebook(notebook string) string {
+	if notebook == "" {
+		return "the top level"
+	}
+	return notebook
+}
@@ -0,0 +1,126 @@
+package local
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNotebooks(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	work, err := store.AddNoteTo("work/ projects/", "todo", "ship it")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if work.Notebook != "work/projects" {
+		t.Errorf("Expected a cleaned notebook path, got %q", work.Notebook)
+	}
+	home, err := store.AddNote("todo", "laundry")
+	if err != nil {
+		t.Fatalf("Expected the same name to be fine in another notebook: %v", err)
+	}
+	if _, err := store.AddNoteTo("work/projects", "todo", "again"); err == nil {
+		t.Error("Expected a duplicate name in one notebook to fail")
+	}
+
+	if id, err := store.FindNoteID("work/projects/todo"); err != nil || id != work.ID {
+		t.Errorf("Expected to find the note by path, got %q (%v)", id, err)
+	}
+	if id, err := store.FindNoteID("todo"); err != nil || id != home.ID {
+		t.Errorf("Expected the top level note for its bare path, got %q (%v)", id, err)
+	}
+
+	if _, err := store.CreateNotebook("archive/2024"); err != nil {
+		t.Fatalf("Failed to create notebook: %v", err)
+	}
+	notebooks, err := store.Notebooks()
+	if err != nil {
+		t.Fatalf("Failed to list notebooks: %v", err)
+	}
+	want := []string{"archive", "archive/2024", "work", "work/projects"}
+	if !slices.Equal(notebooks, want) {
+		t.Errorf("Expected notebooks %v, got %v", want, notebooks)
+	}
+
+	if _, err := store.MoveNote(home.ID, "work/projects"); err == nil {
+		t.Error("Expected moving onto a taken name to fail")
+	}
+	moved, err := store.MoveNote(home.ID, "archive")
+	if err != nil {
+		t.Fatalf("Failed to move note: %v", err)
+	}
+	if moved.Path() != "archive/todo" {
+		t.Errorf("Expected archive/todo, got %s", moved.Path())
+	}
+
+	if err := store.MoveNotebook("work", "archive/2024/work"); err != nil {
+		t.Fatalf("Failed to move notebook: %v", err)
+	}
+	if notes := store.NotesIn("archive/2024/work/projects", false); len(notes) != 1 || notes[0].ID != work.ID {
+		t.Errorf("Expected the nested note to move along, got %v", notes)
+	}
+	if notes := store.NotesIn("archive", true); len(notes) != 2 {
+		t.Errorf("Expected 2 notes under archive, got %d", len(notes))
+	}
+	if err := store.MoveNotebook("archive", "archive/2024"); err == nil {
+		t.Error("Expected moving a notebook into itself to fail")
+	}
+}
+
+func TestNoteNames_NoSlash(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	// work/todo would have the same path as todo in the notebook work
+	if _, err := store.AddNote("work/todo", ""); err == nil {
+		t.Error("Expected a name with a / to fail")
+	}
+	note, err := store.AddNote("todo", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.UpdateNoteName(note.ID, "work/todo"); err == nil {
+		t.Error("Expected renaming to a name with a / to fail")
+	}
+}
+
+func TestExportAll_KeepsNotebookFolders(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "export.zip")
+
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	if _, err := store.AddNoteTo("work/projects", "plan", "a"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.AddNote("loose", "b"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	if err := store.ExportAllTo(target, FormatMarkdown); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	zr, err := zip.OpenReader(target)
+	if err != nil {
+		t.Fatalf("Failed to open export: %v", err)
+	}
+	defer zr.Close()
+
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	if !slices.Equal(names, []string{"loose.md", "manifest.json", "work/projects/plan.md"}) {
+		t.Errorf("Unexpected zip entries: %v", names)
+	}
+}
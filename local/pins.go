@@ -0,0 +1,218 @@
+package local
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortOrder is how notes that aren't pinned are ordered, pinned notes always come first.
+type SortOrder string
+
+const (
+	SortModified SortOrder = "modified" // latest change first
+	SortCreated  SortOrder = "created"  // newest first
+	SortName     SortOrder = "name"     // A to Z by path
+	SortSize     SortOrder = "size"     // longest first
+)
+
+// ParseSortOrder maps the names used on the command line to a sort order.
+func ParseSortOrder(name string) (SortOrder, error) {
+	switch order := SortOrder(strings.ToLower(name)); order {
+	case "":
+		return SortModified, nil
+	case SortModified, SortCreated, SortName, SortSize:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unknown sort order: %s, use modified, created, name or size", name)
+	}
+}
+
+// SortNotes sorts notes in place, pinned notes first in their own order and the
+// rest by order.
+func SortNotes(notes []Note, order SortOrder) {
+	slices.SortStableFunc(notes, func(a, b Note) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+			return 1
+		}
+		if a.Pinned && a.Position != b.Position {
+			return cmp.Compare(a.Position, b.Position)
+		}
+
+		switch order {
+		case SortCreated:
+			return b.CreatedAt.Compare(a.CreatedAt)
+		case SortName:
+			return cmp.Or(
+				strings.Compare(strings.ToLower(a.Path()), strings.ToLower(b.Path())),
+				strings.Compare(a.Path(), b.Path()),
+			)
+		case SortSize:
+			return cmp.Or(
+				cmp.Compare(len(b.Content), len(a.Content)),
+				b.ModifiedAt.Compare(a.ModifiedAt),
+			)
+		default:
+			return b.ModifiedAt.Compare(a.ModifiedAt)
+		}
+	})
+}
+
+// Words counts the words in a note's content.
+func (n Note) Words() int {
+	return len(strings.Fields(n.Content))
+}
+
+// PinNote pins a note so it's listed first. position is its place among the pinned notes
+// counting from 1, 0 puts it last. Pinning a pinned note moves it.
+func (s *Store) PinNote(id string, position int) (Note, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
+
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+
+	order := slices.DeleteFunc(s.pinnedIDs(), func(pinned string) bool { return pinned == id })
+	if position <= 0 || position > len(order) {
+		position = len(order) + 1
+	}
+	order = slices.Insert(order, position-1, id)
+
+	if err := s.putAll(s.numberPinned(order)); err != nil {
+		return Note{}, err
+	}
+
+	pinned, _ := s.cachedNote(id)
+	return pinned, nil
+}
+
+// UnpinNote puts a pinned note back with the others.
+func (s *Store) UnpinNote(id string) (Note, error) {
+	return s.updateFlags(id, func(note *Note) {
+		note.Pinned = false
+		note.Position = 0
+	})
+}
+
+// SetFavorite marks a note as a favorite, or unmarks it.
+func (s *Store) SetFavorite(id string, favorite bool) (Note, error) {
+	return s.updateFlags(id, func(note *Note) {
+		note.Favorite = favorite
+	})
+}
+
+// ReorderPinned puts the pinned notes in the order of ids. Pinned notes left out
+// keep their order after them, ids that aren't pinned are pinned.
+func (s *Store) ReorderPinned(ids ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, id := range ids {
+		if s.noteIndex(id) == -1 {
+			return fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+		}
+	}
+
+	order := slices.Clone(ids)
+	for _, id := range s.pinnedIDs() {
+		if !slices.Contains(order, id) {
+			order = append(order, id)
+		}
+	}
+
+	return s.putAll(s.numberPinned(order))
+}
+
+// pinnedIDs returns the pinned notes in their order. The caller must hold a lock.
+func (s *Store) pinnedIDs() []string {
+	var pinned []Note
+	for _, note := range s.Notes {
+		if note.Pinned {
+			pinned = append(pinned, note)
+		}
+	}
+	SortNotes(pinned, SortName)
+
+	ids := make([]string, len(pinned))
+	for i, note := range pinned {
+		ids[i] = note.ID
+	}
+	return ids
+}
+
+// numberPinned pins the notes in order at positions 1, 2 and on, returning the ones that
+// changed. Notes that were already pinned and only move aren't edited, they keep their
+// version and only their time moves so the new order syncs. The caller must hold the
+// write lock.
+func (s *Store) numberPinned(order []string) []Note {
+	var changed []Note
+	for n, id := range order {
+		note, _ := s.cachedNote(id)
+		if note.Pinned && note.Position == n+1 {
+			continue
+		}
+
+		updated := note
+		if note.Pinned {
+			updated.ModifiedAt = time.Now()
+		} else {
+			updated = note.newVersion()
+		}
+		updated.Pinned = true
+		updated.Position = n + 1
+		changed = append(changed, updated)
+	}
+
+	return changed
+}
+
+// updateFlags changes a note's pinned or favorite flags, like updateTags does its tags.
+func (s *Store) updateFlags(id string, change func(*Note)) (Note, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
+
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+
+	updated := s.Notes[i]
+	change(&updated)
+	if updated.Pinned == s.Notes[i].Pinned && updated.Position == s.Notes[i].Position && updated.Favorite == s.Notes[i].Favorite {
+		return s.Notes[i], nil
+	}
+
+	updated = s.Notes[i].newVersion()
+	change(&updated)
+
+	if err := s.put(updated); err != nil {
+		return Note{}, err
+	}
+	s.Notes[i] = updated
+	s.reindex(updated)
+
+	return updated, nil
+}
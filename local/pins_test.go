@@ -0,0 +1,180 @@
+package local
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func notePaths(notes []Note) []string {
+	var paths []string
+	for _, note := range notes {
+		paths = append(paths, note.Path())
+	}
+	return paths
+}
+
+func TestPinNote_ListsPinnedFirst(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	ids := make(map[string]string)
+	for _, name := range []string{"alpha", "beta", "gamma", "delta"} {
+		note, err := store.AddNote(name, "x")
+		if err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		ids[name] = note.ID
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := store.PinNote(ids["gamma"], 0); err != nil {
+		t.Fatalf("Failed to pin note: %v", err)
+	}
+	if _, err := store.PinNote(ids["alpha"], 0); err != nil {
+		t.Fatalf("Failed to pin note: %v", err)
+	}
+	// Pinning again moves it
+	if _, err := store.PinNote(ids["alpha"], 1); err != nil {
+		t.Fatalf("Failed to pin note: %v", err)
+	}
+
+	reopened := &Store{}
+	if err := reopened.InitAt(store.Dir()); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	notes, err := reopened.GetNotes()
+	if err != nil {
+		t.Fatalf("Failed to get notes: %v", err)
+	}
+	if got := notePaths(notes); !slices.Equal(got, []string{"alpha", "gamma", "delta", "beta"}) {
+		t.Errorf("Expected pinned notes first then newest, got %v", got)
+	}
+
+	if err := store.ReorderPinned(ids["gamma"]); err != nil {
+		t.Fatalf("Failed to reorder: %v", err)
+	}
+	if _, err := store.UnpinNote(ids["alpha"]); err != nil {
+		t.Fatalf("Failed to unpin note: %v", err)
+	}
+	notes = slices.Clone(store.Notes)
+	SortNotes(notes, SortName)
+	if got := notePaths(notes); !slices.Equal(got, []string{"gamma", "alpha", "beta", "delta"}) {
+		t.Errorf("Expected gamma pinned then the rest by name, got %v", got)
+	}
+	SortNotes(notes, SortCreated)
+	if got := notePaths(notes); !slices.Equal(got, []string{"gamma", "delta", "beta", "alpha"}) {
+		t.Errorf("Expected gamma pinned then the rest newest first, got %v", got)
+	}
+}
+
+func TestPinNote_ShiftingIsNotAnEdit(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	var ids []string
+	for _, name := range []string{"alpha", "beta", "gamma"} {
+		note, err := store.AddNote(name, "x")
+		if err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		ids = append(ids, note.ID)
+	}
+	for _, id := range ids[:2] {
+		if _, err := store.PinNote(id, 0); err != nil {
+			t.Fatalf("Failed to pin note: %v", err)
+		}
+	}
+
+	pinned, err := store.PinNote(ids[2], 1)
+	if err != nil {
+		t.Fatalf("Failed to pin note: %v", err)
+	}
+	if pinned.Version != 2 {
+		t.Errorf("Expected the newly pinned note at version 2, got %d", pinned.Version)
+	}
+	if err := store.ReorderPinned(ids[1]); err != nil {
+		t.Fatalf("Failed to reorder: %v", err)
+	}
+
+	for _, id := range ids[:2] {
+		note, err := store.GetNoteFromID(id)
+		if err != nil {
+			t.Fatalf("Failed to get note: %v", err)
+		}
+		if note.Version != 2 {
+			t.Errorf("Expected %s to stay at version 2 after moving, got %d", note.Name, note.Version)
+		}
+		revisions, err := store.History(id)
+		if err != nil {
+			t.Fatalf("Failed to get history: %v", err)
+		}
+		if len(revisions) != 1 {
+			t.Errorf("Expected no revisions for %s from moving it, got %d", note.Name, len(revisions)-1)
+		}
+	}
+}
+
+func TestSetFavorite(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := store.AddNote("ref", "x")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	favorite, err := store.SetFavorite(note.ID, true)
+	if err != nil || !favorite.Favorite || favorite.Version != 2 {
+		t.Fatalf("Expected a new favorite version, got %+v (%v)", favorite, err)
+	}
+	// Nothing to change, no new version
+	again, err := store.SetFavorite(note.ID, true)
+	if err != nil || again.Version != 2 {
+		t.Errorf("Expected no new version, got %+v (%v)", again, err)
+	}
+
+	if _, err := ParseSortOrder("color"); err == nil {
+		t.Error("Expected an unknown sort order to fail")
+	}
+}
+
+func TestSortNotes_BySize(t *testing.T) {
+	notes := []Note{
+		{Name: "short", Content: "one"},
+		{Name: "long", Content: "one two three"},
+		{Name: "pinned", Content: "", Pinned: true, Position: 1},
+	}
+
+	SortNotes(notes, SortSize)
+	if got := notePaths(notes); !slices.Equal(got, []string{"pinned", "long", "short"}) {
+		t.Errorf("Expected pinned then longest first, got %v", got)
+	}
+	if words := notes[1].Words(); words != 3 {
+		t.Errorf("Expected 3 words, got %d", words)
+	}
+}
+
+func TestStoreErrors_AreSentinels(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	if _, err := store.AddNote("ref", "x"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	if _, err := store.PinNote("missing", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.FindNoteID("missing"); !errors.Is(err, ErrNotFound) || err.Error() != "could not find note with name: missing" {
+		t.Errorf("Expected ErrNotFound with the name, got %v", err)
+	}
+	if _, err := store.AddNote("ref", "y"); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("Expected ErrNameTaken, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package local
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// minIDPrefix is the shortest ID prefix ResolveNote looks notes up by, shorter ones
+// match too many notes to be useful.
+const minIDPrefix = 4
+
+// AmbiguousError is returned by ResolveNote when a query could mean more than one
+// note, or when it only comes close to a note. Matches holds them, best match first.
+type AmbiguousError struct {
+	Query   string
+	Matches []Note
+	Fuzzy   bool // Nothing is called Query, the matches are suggestions
+}
+
+func (e *AmbiguousError) Error() string {
+	paths := make([]string, 0, 3)
+	for _, note := range e.Matches[:min(len(e.Matches), 3)] {
+		paths = append(paths, note.Path())
+	}
+	if len(e.Matches) > 3 {
+		paths = append(paths, fmt.Sprintf("and %d more", len(e.Matches)-3))
+	}
+
+	if e.Fuzzy {
+		return fmt.Sprintf("%s note with name: %s, did you mean %s?", ErrNotFound, e.Query, strings.Join(paths, ", "))
+	}
+	return fmt.Sprintf("%s matches more than one note: %s, use its path or ID", e.Query, strings.Join(paths, ", "))
+}
+
+// Unwrap makes a fuzzy match an ErrNotFound, it's only a suggestion.
+func (e *AmbiguousError) Unwrap() error {
+	if e.Fuzzy {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResolveNote finds the note among notes that query means, trying in turn its path, its
+// name, its ID or the start of it and its path or name in any case. It stops at the first
+// step that matches anything, returning an *AmbiguousError when that's more than one note.
+// When none do, a fuzzy match on the path suggests notes, so "mtg" suggests work/meeting,
+// but even a single one is only returned as a Fuzzy *AmbiguousError for the caller to
+// confirm, a typo shouldn't pick the note to delete.
+func ResolveNote(notes []Note, query string) (Note, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return Note{}, fmt.Errorf("%w note with an empty name", ErrNotFound)
+	}
+
+	steps := []func(Note) bool{
+		func(n Note) bool { return n.Path() == query },
+		func(n Note) bool { return n.Name == query },
+		func(n Note) bool { return len(query) >= minIDPrefix && strings.HasPrefix(n.ID, strings.ToLower(query)) },
+		func(n Note) bool { return strings.EqualFold(n.Path(), query) || strings.EqualFold(n.Name, query) },
+	}
+	for _, step := range steps {
+		var found []Note
+		for _, note := range notes {
+			if step(note) {
+				found = append(found, note)
+			}
+		}
+		if len(found) > 0 {
+			return oneNote(query, found)
+		}
+	}
+
+	paths := make([]string, len(notes))
+	for i, note := range notes {
+		paths[i] = note.Path()
+	}
+	var found []Note
+	for _, match := range fuzzy.Find(query, paths) {
+		found = append(found, notes[match.Index])
+	}
+	if len(found) > 0 {
+		return Note{}, &AmbiguousError{Query: query, Matches: found, Fuzzy: true}
+	}
+
+	return Note{}, fmt.Errorf("%w note with name: %s", ErrNotFound, query)
+}
+
+func oneNote(query string, found []Note) (Note, error) {
+	if len(found) > 1 {
+		return Note{}, &AmbiguousError{Query: query, Matches: found}
+	}
+	return found[0], nil
+}
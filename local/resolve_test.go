@@ -0,0 +1,56 @@
+package local
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolveNote(t *testing.T) {
+	notes := []Note{
+		{ID: "1a2b3c4d-0000-4000-8000-000000000001", Notebook: "work", Name: "meeting notes"},
+		{ID: "1a2b9999-0000-4000-8000-000000000002", Notebook: "home", Name: "meeting notes"},
+		{ID: "77aa0000-0000-4000-8000-000000000003", Name: "Groceries"},
+		{ID: "88bb0000-0000-4000-8000-000000000004", Name: "ideas"},
+	}
+
+	tests := []struct {
+		query   string
+		want    string // path, empty when the query should fail
+		matches int    // notes an *AmbiguousError offers
+		fuzzy   bool
+	}{
+		{"work/meeting notes", "work/meeting notes", 0, false},
+		{"  ideas ", "ideas", 0, false},
+		{"meeting notes", "", 2, false},
+		{"1a2b3c", "work/meeting notes", 0, false},
+		{"1a2b", "", 2, false},
+		{"77a", "", 0, false}, // too short for an ID prefix, and no name has it in it
+		{"groceries", "Groceries", 0, false},
+		{"grcr", "", 1, true}, // a fuzzy match is only ever a suggestion
+		{"h/mtg", "", 1, true},
+		{"mtg", "", 2, true},
+		{"zzz", "", 0, false},
+		{" ", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		note, err := ResolveNote(notes, tt.query)
+
+		var ambiguous *AmbiguousError
+		switch {
+		case tt.matches > 0:
+			if !errors.As(err, &ambiguous) || len(ambiguous.Matches) != tt.matches || ambiguous.Fuzzy != tt.fuzzy {
+				t.Errorf("ResolveNote(%q) = %v, %v, want %d matches to pick from", tt.query, note.Path(), err, tt.matches)
+			}
+			if tt.fuzzy && !errors.Is(err, ErrNotFound) {
+				t.Errorf("ResolveNote(%q) = %v, want a fuzzy match to be ErrNotFound", tt.query, err)
+			}
+		case tt.want == "":
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("ResolveNote(%q) = %v, %v, want ErrNotFound", tt.query, note.Path(), err)
+			}
+		case err != nil || note.Path() != tt.want:
+			t.Errorf("ResolveNote(%q) = %v, %v, want %s", tt.query, note.Path(), err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+package local
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// nameWeight makes a hit in a note's name count for more than one in its content.
+const nameWeight = 3
+
+// snippetWidth is roughly how many characters of content a search snippet shows.
+const snippetWidth = 80
+
+// SearchResult is a note that matched a search. Snippet is the part of the content
+// around the first match, empty when only the name matched.
+type SearchResult struct {
+	Note    Note
+	Score   float64
+	Snippet string
+}
+
+// searchIndex is an inverted index over the names and content of the live notes.
+// It maps every term to the notes containing it and the token positions it is at,
+// positions are kept so phrases can be matched without rereading the notes.
+type searchIndex struct {
+	postings map[string]map[string]*posting
+	docs     map[string]indexedNote
+}
+
+type posting struct {
+	name    []int
+	content []int
+}
+
+// indexedNote remembers which revision of a note is in the index and which terms to
+// drop when it changes.
+type indexedNote struct {
+	modifiedAt time.Time
+	terms      []string
+}
+
+type token struct {
+	text       string
+	start, end int
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[string]*posting),
+		docs:     make(map[string]indexedNote),
+	}
+}
+
+// add indexes a note, replacing whatever was indexed for its ID before.
+func (idx *searchIndex) add(note Note) {
+	idx.remove(note.ID)
+
+	var terms []string
+	hit := func(term string) *posting {
+		notes, ok := idx.postings[term]
+		if !ok {
+			notes = make(map[string]*posting)
+			idx.postings[term] = notes
+		}
+		p, ok := notes[note.ID]
+		if !ok {
+			p = &posting{}
+			notes[note.ID] = p
+			terms = append(terms, term)
+		}
+		return p
+	}
+
+	for i, tok := range tokenize(note.Name) {
+		p := hit(tok.text)
+		p.name = append(p.name, i)
+	}
+	for i, tok := range tokenize(note.Content) {
+		p := hit(tok.text)
+		p.content = append(p.content, i)
+	}
+
+	idx.docs[note.ID] = indexedNote{modifiedAt: note.ModifiedAt, terms: terms}
+}
+
+func (idx *searchIndex) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, term := range doc.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// refresh brings the index in line with notes after a reload. Only notes that were
+// added, changed or dropped since the last refresh are touched.
+func (idx *searchIndex) refresh(notes []Note) {
+	live := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		live[note.ID] = true
+		if doc, ok := idx.docs[note.ID]; !ok || !doc.modifiedAt.Equal(note.ModifiedAt) {
+			idx.add(note)
+		}
+	}
+
+	for id := range idx.docs {
+		if !live[id] {
+			idx.remove(id)
+		}
+	}
+}
+
+// reindex is called after a note changed in memory, it updates the search index and
+// the link graph. The caller must hold the write lock.
+func (s *Store) reindex(notes ...Note) {
+	if s.index == nil {
+		return
+	}
+
+	for _, note := range notes {
+		if note.DeletedAt.IsZero() {
+			s.index.add(note)
+			s.links.add(note)
+		} else {
+			s.index.remove(note.ID)
+			s.links.remove(note.ID)
+		}
+	}
+}
+
+// Search looks through the names and content of the live notes. The query is made of
+// words that must all appear, "quoted phrases" that must appear in that order,
+// words ending in * that match as a prefix, and words or phrases starting with -
+// that must not appear. Results are ranked with name matches above content matches,
+// and rarer words counting for more.
+func (s *Store) Search(query string) ([]SearchResult, error) {
+	clauses := parseQuery(query)
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.index == nil {
+		return nil, nil
+	}
+
+	scores := make(map[string]float64)
+	matched := make(map[string]bool)
+	for _, note := range s.Notes {
+		matched[note.ID] = true
+	}
+
+	var include []queryClause
+	for _, clause := range clauses {
+		hits := s.index.match(clause)
+		for id := range matched {
+			_, hit := hits[id]
+			if hit == clause.exclude {
+				delete(matched, id)
+			}
+		}
+		if !clause.exclude {
+			include = append(include, clause)
+			for id, score := range hits {
+				scores[id] += score
+			}
+		}
+	}
+
+	var results []SearchResult
+	for _, note := range s.Notes {
+		if !matched[note.ID] {
+			continue
+		}
+		results = append(results, SearchResult{
+			Note:    note,
+			Score:   scores[note.ID],
+			Snippet: snippet(note.Content, include),
+		})
+	}
+
+	// Notes are kept newest first, a stable sort keeps that order among equal scores
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results, nil
+}
+
+// queryClause is one part of a search query, a single term is a phrase of length one.
+type queryClause struct {
+	terms   []string
+	prefix  bool
+	exclude bool
+}
+
+func parseQuery(query string) []queryClause {
+	var clauses []queryClause
+
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		var clause queryClause
+		if rest[0] == '-' {
+			clause.exclude = true
+			rest = rest[1:]
+		}
+
+		var raw string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end == -1 {
+				// An unclosed quote runs to the end of the query
+				raw, rest = rest[1:], ""
+			} else {
+				raw, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end == -1 {
+				end = len(rest)
+			}
+			raw, rest = rest[:end], rest[end:]
+			clause.prefix = strings.HasSuffix(raw, "*")
+		}
+		rest = strings.TrimSpace(rest)
+
+		for _, tok := range tokenize(raw) {
+			clause.terms = append(clause.terms, tok.text)
+		}
+		if len(clause.terms) == 0 {
+			continue
+		}
+		// Only single words can be prefixes
+		clause.prefix = clause.prefix && len(clause.terms) == 1
+
+		clauses = append(clauses, clause)
+	}
+
+	return clauses
+}
+
+// match returns the notes a clause matches and how well.
+func (idx *searchIndex) match(clause queryClause) map[string]float64 {
+	hits := make(map[string]float64)
+
+	if clause.prefix {
+		for term := range idx.postings {
+			if strings.HasPrefix(term, clause.terms[0]) {
+				for id, score := range idx.score(idx.postings[term]) {
+					hits[id] += score
+				}
+			}
+		}
+		return hits
+	}
+
+	if len(clause.terms) == 1 {
+		return idx.score(idx.postings[clause.terms[0]])
+	}
+
+	// Phrase: every word has to be there, then check they line up
+	first := idx.postings[clause.terms[0]]
+	for id := range first {
+		name, content := idx.phraseCount(id, clause.terms)
+		if name+content == 0 {
+			continue
+		}
+		hits[id] = idx.weight(float64(name), float64(content), len(first)) * float64(len(clause.terms))
+	}
+
+	return hits
+}
+
+func (idx *searchIndex) score(notes map[string]*posting) map[string]float64 {
+	scores := make(map[string]float64, len(notes))
+	for id, p := range notes {
+		scores[id] = idx.weight(float64(len(p.name)), float64(len(p.content)), len(notes))
+	}
+	return scores
+}
+
+// weight is a plain tf-idf, with name hits scaled up and term frequency dampened so
+// a word repeated all over a long note doesn't drown out everything else.
+func (idx *searchIndex) weight(name, content float64, noteCount int) float64 {
+	idf := math.Log(1 + float64(len(idx.docs))/float64(noteCount))
+	return (nameWeight*name + math.Log1p(content)) * idf
+}
+
+// phraseCount counts how often terms appear one after the other in a note's name and content.
+func (idx *searchIndex) phraseCount(id string, terms []string) (name, content int) {
+	postings := make([]*posting, len(terms))
+	for i, term := range terms {
+		p, ok := idx.postings[term][id]
+		if !ok {
+			return 0, 0
+		}
+		postings[i] = p
+	}
+
+	count := func(field func(*posting) []int) int {
+		n := 0
+		for _, start := range field(postings[0]) {
+			found := true
+			for i := 1; i < len(postings) && found; i++ {
+				found = containsInt(field(postings[i]), start+i)
+			}
+			if found {
+				n++
+			}
+		}
+		return n
+	}
+
+	name = count(func(p *posting) []int { return p.name })
+	content = count(func(p *posting) []int { return p.content })
+	return name, content
+}
+
+func containsInt(sorted []int, v int) bool {
+	i := sort.SearchInts(sorted, v)
+	return i < len(sorted) && sorted[i] == v
+}
+
+// snippet cuts the content down to the area around the first matching clause.
+func snippet(content string, clauses []queryClause) string {
+	tokens := tokenize(content)
+
+	at := -1
+	for i := range tokens {
+		for _, clause := range clauses {
+			if clauseAt(tokens, i, clause) {
+				at = i
+				break
+			}
+		}
+		if at != -1 {
+			break
+		}
+	}
+	if at == -1 {
+		return ""
+	}
+
+	start := max(tokens[at].start-snippetWidth/4, 0)
+	end := min(start+snippetWidth, len(content))
+	// Use the whole width when the match is near the end
+	start = max(end-snippetWidth, 0)
+	// Don't cut words in half, or the characters in them
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(content[:start])
+		if unicode.IsSpace(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(content) {
+		r, size := utf8.DecodeRuneInString(content[end:])
+		if unicode.IsSpace(r) {
+			break
+		}
+		end += size
+	}
+
+	text := strings.Join(strings.Fields(content[start:end]), " ")
+	if start > 0 {
+		text = "..." + text
+	}
+	if end < len(content) {
+		text += "..."
+	}
+
+	return text
+}
+
+func clauseAt(tokens []token, i int, clause queryClause) bool {
+	if clause.prefix {
+		return strings.HasPrefix(tokens[i].text, clause.terms[0])
+	}
+	if i+len(clause.terms) > len(tokens) {
+		return false
+	}
+	for j, term := range clause.terms {
+		if tokens[i+j].text != term {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize splits text into lower cased words of letters and digits, with their byte offsets.
+func tokenize(text string) []token {
+	var tokens []token
+
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start == -1:
+			start = i
+		case !isWord && start != -1:
+			tokens = append(tokens, token{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start != -1 {
+		tokens = append(tokens, token{strings.ToLower(text[start:]), start, len(text)})
+	}
+
+	return tokens
+}
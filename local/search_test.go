@@ -0,0 +1,126 @@
+package local
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSearch(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	notes := map[string]string{
+		"groceries": "milk, eggs and a loaf of sourdough bread",
+		"bread":     "try the overnight sourdough recipe again",
+		"meeting":   "talk about the bread budget and the new hire",
+		"travel":    "book trains for the spring trip",
+	}
+	ids := make(map[string]string)
+	for name, content := range notes {
+		note, err := store.AddNote(name, content)
+		if err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		ids[name] = note.ID
+	}
+
+	names := func(query string) []string {
+		t.Helper()
+		results, err := store.Search(query)
+		if err != nil {
+			t.Fatalf("Failed to search %q: %v", query, err)
+		}
+		var found []string
+		for _, result := range results {
+			found = append(found, result.Note.Name)
+		}
+		return found
+	}
+
+	tests := []struct {
+		query string
+		want  []string // any order
+		top   string   // expected best match, if any
+	}{
+		{"bread", []string{"bread", "groceries", "meeting"}, "bread"},
+		{"Sourdough bread", []string{"groceries", "bread"}, ""},
+		{`"sourdough bread"`, []string{"groceries"}, ""},
+		{"sour*", []string{"groceries", "bread"}, ""},
+		{"bread -budget", []string{"bread", "groceries"}, "bread"},
+		{`-"sourdough bread" bread`, []string{"bread", "meeting"}, "bread"},
+		{"airplane", nil, ""},
+	}
+
+	for _, tt := range tests {
+		got := names(tt.query)
+		sorted := slices.Sorted(slices.Values(got))
+		want := slices.Sorted(slices.Values(tt.want))
+		if !slices.Equal(sorted, want) {
+			t.Errorf("Search %q: expected %v, got %v", tt.query, tt.want, got)
+			continue
+		}
+		if tt.top != "" && got[0] != tt.top {
+			t.Errorf("Search %q: expected %s to rank first, got %v", tt.query, tt.top, got)
+		}
+	}
+
+	results, err := store.Search("trains")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected one result for trains, got %v (%v)", results, err)
+	}
+	if !strings.Contains(results[0].Snippet, "book trains") {
+		t.Errorf("Expected snippet around the match, got %q", results[0].Snippet)
+	}
+
+	// The index follows updates and deletes
+	if _, err := store.UpdateNoteContent(ids["travel"], "fly to the coast"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+	if got := names("trains"); len(got) != 0 {
+		t.Errorf("Expected no match for old content, got %v", got)
+	}
+	if got := names("coast"); len(got) != 1 {
+		t.Errorf("Expected a match for new content, got %v", got)
+	}
+	if err := store.DeleteNote(ids["meeting"]); err != nil {
+		t.Fatalf("Failed to delete note: %v", err)
+	}
+	if got := names("budget"); len(got) != 0 {
+		t.Errorf("Expected trashed notes to be left out, got %v", got)
+	}
+
+	if _, err := store.Search("  - \"\" "); err == nil {
+		t.Error("Expected an error for an empty query")
+	}
+}
+
+func TestSnippet_LongContent(t *testing.T) {
+	content := strings.Repeat("filler words here ", 20) + "the needle sits in the middle " + strings.Repeat("more filler ", 20)
+	got := snippet(content, parseQuery("needle"))
+
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected snippet trimmed on both ends, got %q", got)
+	}
+	if !strings.Contains(got, "needle") || len(got) > snippetWidth+30 {
+		t.Errorf("Expected a short snippet containing the match, got %q", got)
+	}
+}
+
+func TestSnippet_NonASCII(t *testing.T) {
+	// Shift the text after the match so the cut lands at every byte of the accented letters
+	for shift := range 4 {
+		content := strings.Repeat("voilà café déjà ", 20) + "aiguille " + strings.Repeat("x", shift) + " " + strings.Repeat("voilà ", 40)
+		got := snippet(content, parseQuery("aiguille"))
+
+		if !utf8.ValidString(got) {
+			t.Errorf("Expected valid UTF-8 with a shift of %d, got %q", shift, got)
+		}
+		if !strings.Contains(got, "aiguille") {
+			t.Errorf("Expected the snippet to contain the match, got %q", got)
+		}
+	}
+}
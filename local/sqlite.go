@@ -0,0 +1,221 @@
+package local
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite" // pure Go driver, registers as "sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS notes (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	modified_at TEXT NOT NULL,
+	data        TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notes_name ON notes (name);
+`
+
+// sqliteBackend stores one row per note, so a change only writes that note.
+// The id and name columns are there to look notes up by, see Lookup and LookupName, the
+// rest of the note is kept as JSON in data so new Note fields don't need a schema change.
+//
+// Everything goes through one connection. SQLite's data_version only changes for commits
+// made on other connections, so that's how Changed sees another process's writes.
+type sqliteBackend struct {
+	db      *sql.DB
+	conn    *sql.Conn
+	version int64 // data_version as of the last Load, -1 before it
+}
+
+func openSQLiteBackend(path string) (*sqliteBackend, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error opening sqlite database: %w", err)
+	}
+
+	if _, err := conn.ExecContext(context.Background(), sqliteSchema); err != nil {
+		conn.Close()
+		db.Close()
+		return nil, fmt.Errorf("error creating sqlite schema: %w", err)
+	}
+
+	return &sqliteBackend{db: db, conn: conn, version: -1}, nil
+}
+
+func (b *sqliteBackend) dataVersion() (int64, error) {
+	var version int64
+	if err := b.conn.QueryRowContext(context.Background(), `PRAGMA data_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("error reading sqlite data version: %w", err)
+	}
+	return version, nil
+}
+
+// Changed reports whether another connection committed since the last Load.
+func (b *sqliteBackend) Changed() (bool, error) {
+	if b.version == -1 {
+		return true, nil
+	}
+
+	version, err := b.dataVersion()
+	if err != nil {
+		return true, err
+	}
+	return version != b.version, nil
+}
+
+func (b *sqliteBackend) Load() ([]Note, error) {
+	// Read first, a commit landing in between only means one reload too many
+	version, err := b.dataVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := b.query(`SELECT data FROM notes`)
+	if err != nil {
+		return nil, err
+	}
+	b.version = version
+
+	return notes, nil
+}
+
+func (b *sqliteBackend) Lookup(id string) (Note, bool, error) {
+	notes, err := b.query(`SELECT data FROM notes WHERE id = ?`, id)
+	if err != nil || len(notes) == 0 {
+		return Note{}, false, err
+	}
+	return notes[0], true, nil
+}
+
+func (b *sqliteBackend) LookupName(name string) ([]Note, error) {
+	return b.query(`SELECT data FROM notes WHERE name = ?`, name)
+}
+
+// query reads the notes a SELECT of the data column returns.
+func (b *sqliteBackend) query(query string, args ...any) ([]Note, error) {
+	rows, err := b.conn.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("error reading note row: %w", err)
+		}
+
+		var note Note
+		if err := json.Unmarshal([]byte(data), &note); err != nil {
+			return nil, fmt.Errorf("error unmarshalling note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying notes: %w", err)
+	}
+
+	return notes, nil
+}
+
+func (b *sqliteBackend) Put(notes ...Note) error {
+	tx, err := b.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO notes (id, name, modified_at, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			modified_at = excluded.modified_at,
+			data = excluded.data`)
+	if err != nil {
+		return fmt.Errorf("error preparing note insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, note := range notes {
+		data, err := json.Marshal(note)
+		if err != nil {
+			return fmt.Errorf("error marshalling note: %w", err)
+		}
+
+		_, err = stmt.Exec(note.ID, note.Name, note.ModifiedAt.UTC().Format(time.RFC3339Nano), string(data))
+		if err != nil {
+			return fmt.Errorf("error saving note %s: %w", note.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error saving notes: %w", err)
+	}
+
+	return nil
+}
+
+func (b *sqliteBackend) Remove(ids ...string) error {
+	tx, err := b.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.Exec(`DELETE FROM notes WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("error deleting note %s: %w", id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting notes: %w", err)
+	}
+
+	return nil
+}
+
+func (b *sqliteBackend) Close() error {
+	b.conn.Close()
+	return b.db.Close()
+}
+
+// migrateJSON copies the notes of an existing biji.json into the database once.
+// The JSON file is renamed afterwards so it isn't imported twice but can still be recovered.
+func (b *sqliteBackend) migrateJSON(jsonPath string) error {
+	if _, err := os.Stat(jsonPath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	notes, err := (&jsonBackend{path: jsonPath}).Load()
+	if err != nil {
+		return fmt.Errorf("error reading %s for migration: %w", jsonPath, err)
+	}
+
+	if len(notes) > 0 {
+		if err := b.Put(notes...); err != nil {
+			return fmt.Errorf("error migrating notes: %w", err)
+		}
+	}
+
+	if err := os.Rename(jsonPath, jsonPath+".migrated"); err != nil {
+		return fmt.Errorf("error renaming %s after migration: %w", jsonPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSQLite_MigratesJSON(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BIJI_STORAGE", "sqlite")
+
+	existing := []Note{{
+		ID:         "existing-id",
+		Name:       "from json",
+		Content:    "migrated content",
+		CreatedAt:  time.Now(),
+		ModifiedAt: time.Now(),
+	}}
+	data, err := json.Marshal(existing)
+	if err != nil {
+		t.Fatalf("Failed to marshal notes: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "biji.json"), data, 0o644); err != nil {
+		t.Fatalf("Failed to write biji.json: %v", err)
+	}
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create sqlite store: %v", err)
+	}
+	defer store.Close()
+
+	if len(store.Notes) != 1 || store.Notes[0].Content != "migrated content" {
+		t.Fatalf("Expected the json note to be migrated, got %v", store.Notes)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "biji.json")); !os.IsNotExist(err) {
+		t.Error("Expected biji.json to be moved aside after migration")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "biji.json.migrated")); err != nil {
+		t.Errorf("Expected biji.json.migrated to exist: %v", err)
+	}
+}
+
+func TestSQLite_PersistsChanges(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BIJI_STORAGE", "sqlite")
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create sqlite store: %v", err)
+	}
+
+	kept, err := store.AddNote("kept", "first")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	deleted, err := store.AddNote("deleted", "gone")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	if _, err := store.UpdateNoteContent(kept.ID, "second"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+	if _, err := store.UpdateNoteName(kept.ID, "renamed"); err != nil {
+		t.Fatalf("Failed to rename note: %v", err)
+	}
+	if err := store.DeleteNote(deleted.ID); err != nil {
+		t.Fatalf("Failed to delete note: %v", err)
+	}
+	store.Close()
+
+	// The database is picked up again without the env var
+	os.Unsetenv("BIJI_STORAGE")
+	reopened := &Store{}
+	if err := reopened.InitAt(dir); err != nil {
+		t.Fatalf("Failed to reopen sqlite store: %v", err)
+	}
+	defer reopened.Close()
+
+	if len(reopened.Notes) != 1 {
+		t.Fatalf("Expected 1 note after reopening, got %d", len(reopened.Notes))
+	}
+
+	note := reopened.Notes[0]
+	if note.Name != "renamed" || note.Content != "second" {
+		t.Errorf("Expected renamed note with updated content, got %s: %s", note.Name, note.Content)
+	}
+}
+
+func TestSQLite_LooksUpByIndex(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BIJI_STORAGE", "sqlite")
+
+	store := &Store{}
+	if err := store.InitAt(dir); err != nil {
+		t.Fatalf("Failed to create sqlite store: %v", err)
+	}
+	defer store.Close()
+
+	top, err := store.AddNote("todo", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	work, err := store.AddNoteTo("work", "todo", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	gone, err := store.AddNote("gone", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if err := store.DeleteNote(gone.ID); err != nil {
+		t.Fatalf("Failed to delete note: %v", err)
+	}
+
+	var id, parent, unused int
+	var plan string
+	row := store.backend.(*sqliteBackend).conn.QueryRowContext(context.Background(), `EXPLAIN QUERY PLAN SELECT data FROM notes WHERE name = ?`, "todo")
+	if err := row.Scan(&id, &parent, &unused, &plan); err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	if !strings.Contains(plan, "notes_name") {
+		t.Errorf("Expected name lookups to use the notes_name index, got %q", plan)
+	}
+
+	if found, err := store.FindNoteID("work/todo"); err != nil || found != work.ID {
+		t.Errorf("Expected work/todo to find %s, got %s (%v)", work.ID, found, err)
+	}
+	if found, err := store.FindNoteID("todo"); err != nil || found != top.ID {
+		t.Errorf("Expected todo to find the top level note %s, got %s (%v)", top.ID, found, err)
+	}
+	if _, err := store.GetNoteFromID(gone.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a trashed note not to be found, got %v", err)
+	}
+	if _, err := store.AddNoteTo("work", "todo", ""); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("Expected the name to be taken, got %v", err)
+	}
+
+	// Lookups agree with Notes while another store's note isn't loaded yet
+	other := &Store{}
+	if err := other.InitAt(dir); err != nil {
+		t.Fatalf("Failed to open sqlite store: %v", err)
+	}
+	defer other.Close()
+	theirs, err := other.AddNote("theirs", "")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.GetNoteFromID(theirs.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the other store's note not to be found before a reload, got %v", err)
+	}
+}
@@ -1,12 +1,13 @@
 package local
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -15,333 +16,532 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrNotFound is returned, wrapped, when a note, notebook or version doesn't exist.
+	ErrNotFound = errors.New("could not find")
+	// ErrNameTaken is returned, wrapped, when a note already has the name in its notebook.
+	ErrNameTaken = errors.New("is already taken")
+)
+
 type Note struct {
 	ID         string    `json:"id"`
+	Notebook   string    `json:"notebook,omitempty"` // Path like work/projects, empty at the top level
 	Name       string    `json:"name"`
 	Content    string    `json:"content"`
+	Tags       []string  `json:"tags,omitempty"` // See AllTags for #hashtags in Content
 	CreatedAt  time.Time `json:"createdAt"`
 	ModifiedAt time.Time `json:"modifiedAt"`
 
-	Version  int       `json:"version"`
-	LastSync time.Time `json:"lastSync"`
+	Pinned   bool `json:"pinned,omitempty"`   // Listed before everything else, see SortNotes
+	Position int  `json:"position,omitempty"` // Order among the pinned notes, from 1
+	Favorite bool `json:"favorite,omitempty"`
+
+	Version  int        `json:"version"`
+	LastSync time.Time  `json:"lastSync"`
+	History  []Revision `json:"history,omitempty"` // Only in JSON exports and notes saved before history got its own files, see moveHistory
+
+	DeletedAt time.Time `json:"deletedAt,omitzero"` // Set while the note is in the trash
 }
 
 type Store struct {
 	dataFile string
+	backend  Backend
 	Notes    []Note       // In-memory cache
+	Trash    []Note       // Deleted notes, kept until they are purged
+	index    *searchIndex // Full text index over Notes, see Search
+	links    *linkGraph   // [[links]] between Notes, see Backlinks
 	mutex    sync.RWMutex // For multithreading
+	stale    bool         // The backend loaded notes that Notes doesn't have, see GetNotes
 }
 
 // Init initializes the storage directory. If the directory does not exist, it creates one.
 func (s *Store) Init() error {
-	var err error // For function level error handling.
-
 	usr, err := user.Current()
 	if err != nil {
 		return fmt.Errorf("could not get current user: %w", err)
 	}
 	// Get OS and set default dir based on results
-	// NOTE currently this is set up to save to a JSON file. However,
-	// in the future I'll consider switching to a SQLite database.
-	// The current implementation is easier for cloud sync learning.
+	// NOTE notes are saved to a JSON file by default since it's easier for
+	// cloud sync learning. SQLite is opt in, see InitAt.
 	var bijiDir string
 	if runtime.GOOS == "windows" {
 		bijiDir = filepath.Join(os.Getenv("APPDATA"), "biji")
 	} else {
 		bijiDir = filepath.Join(usr.HomeDir, ".config", "biji")
 	}
+
+	return s.InitAt(bijiDir)
+}
+
+// InitAt initializes the store in bijiDir instead of the default config directory.
+// Notes are kept in biji.json unless BIJI_STORAGE is set to sqlite or a biji.db
+// already exists, the first SQLite start migrates an existing biji.json.
+func (s *Store) InitAt(bijiDir string) error {
+	var err error // For function level error handling.
+
 	if err = os.MkdirAll(bijiDir, 0o755); err != nil {
 		return fmt.Errorf("error creating biji config directory: %w", err)
 	}
 
-	s.dataFile = filepath.Join(bijiDir, "biji.json")
-	if _, err = os.Stat(s.dataFile); os.IsNotExist(err) {
-		if err = os.WriteFile(s.dataFile, []byte("[]"), 0o644); err != nil {
-			return fmt.Errorf("error creating biji.json: %w", err)
+	jsonFile := filepath.Join(bijiDir, "biji.json")
+	dbFile := filepath.Join(bijiDir, "biji.db")
+
+	_, dbErr := os.Stat(dbFile)
+	if os.Getenv("BIJI_STORAGE") == "sqlite" || dbErr == nil {
+		db, err := openSQLiteBackend(dbFile)
+		if err != nil {
+			return err
+		}
+		if err = db.migrateJSON(jsonFile); err != nil {
+			db.Close()
+			return err
+		}
+		s.dataFile = dbFile
+		s.backend = db
+	} else {
+		s.dataFile = jsonFile
+		s.backend, err = openJSONBackend(jsonFile)
+		if err != nil {
+			return err
 		}
 	}
 
-	notes, err := s.GetNotes()
+	s.mutex.Lock()
+	err = s.load()
+	s.mutex.Unlock()
 	if err != nil {
 		return fmt.Errorf("error loading notes: %w", err)
 	}
-	s.Notes = notes
+
+	if err = s.moveHistory(); err != nil {
+		return fmt.Errorf("error moving history: %w", err)
+	}
+
+	if retention := trashRetention(); retention > 0 {
+		if _, err = s.PurgeTrash(retention); err != nil {
+			return fmt.Errorf("error purging trash: %w", err)
+		}
+	}
 
 	return nil
 }
 
+// Close releases the storage backend.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.backend == nil {
+		return nil
+	}
+	return s.backend.Close()
+}
+
 // GetNoteFromID get's the notes ID in the JSON file from the title.
 func (s *Store) GetNoteFromID(id string) (Note, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	for _, note := range s.Notes {
-		if note.ID == id {
-			return note, nil
-		}
+	if note, ok := s.noteByID(id); ok {
+		return note, nil
 	}
 
-	return Note{}, fmt.Errorf("could not find note with ID: %s", id)
+	return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
 }
 
-// GetNotes reads the JSON file and loads them into memory via an vector of notes.
+// GetNotes reads the notes from storage and loads them into memory via an vector of notes.
+// Notes in the trash are left out.
 func (s *Store) GetNotes() ([]Note, error) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	var notes []Note
+	// Write lock since loading refreshes the backend's own copy
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	notesJSON, err := os.ReadFile(s.dataFile)
+	notes, err := s.backend.Load()
 	if err != nil {
-		return nil, fmt.Errorf("error reading json file: %w", err)
+		return nil, err
 	}
+	s.stale = true
 
-	err = json.Unmarshal(notesJSON, &notes)
+	live, _ := splitTrash(notes)
+
+	return live, nil
+}
+
+// load reads every note from storage into Notes and Trash. The caller must hold the write lock.
+func (s *Store) load() error {
+	notes, err := s.backend.Load()
 	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling json file: %w", err)
+		return err
+	}
+
+	s.Notes, s.Trash = splitTrash(notes)
+	s.stale = false
+
+	if s.index == nil {
+		s.index = newSearchIndex()
+		s.links = newLinkGraph()
 	}
+	s.index.refresh(s.Notes)
+	s.links.refresh(s.Notes)
 
+	return nil
+}
+
+// splitTrash sorts notes by ModifiedAt, newest first, and separates the trashed ones.
+// Pinned notes go before the other live notes, see SortNotes.
+func splitTrash(notes []Note) (live, trash []Note) {
 	sort.Slice(notes, func(i, j int) bool {
 		return notes[i].ModifiedAt.After(notes[j].ModifiedAt)
 	})
 
-	return notes, nil
+	for _, note := range notes {
+		if note.DeletedAt.IsZero() {
+			live = append(live, note)
+		} else {
+			trash = append(trash, note)
+		}
+	}
+	SortNotes(live, SortModified)
+
+	return live, trash
 }
 
 // AddNote takes a name and some content and trims and preps them.
-// It creates an in memory Note and adds it to the JSON file.
+// It creates an in memory Note and adds it to storage.
 func (s *Store) AddNote(name, content string) (*Note, error) {
+	return s.AddNoteTo("", name, content)
+}
+
+// AddNoteTo is AddNote for a note in notebook, "" being the top level.
+// Names only have to be unique within a notebook.
+func (s *Store) AddNoteTo(notebook, name, content string) (*Note, error) {
+	notebook, err := CleanNotebook(notebook)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	trimmedName := strings.TrimSpace(name)
-	id, _ := s.FindNoteID(s.Notes, trimmedName)
-	if id == "" {
+	if !s.nameTaken(notebook, trimmedName, "") {
 		name = trimmedName
 	} else {
-		return &Note{}, fmt.Errorf("name: %s, is already taken", trimmedName)
+		return &Note{}, fmt.Errorf("name: %s, %w", trimmedName, ErrNameTaken)
 	}
 
 	noteID := uuid.NewString()
 	note := Note{
 		ID:         noteID,
+		Notebook:   notebook,
 		Name:       name,
 		Content:    content,
 		CreatedAt:  time.Now(),
 		ModifiedAt: time.Now(),
+		Version:    1,
 	}
 
-	s.Notes = append(s.Notes, note)
-
-	jsonData, err := json.Marshal(s.Notes)
-	if err != nil {
-		return nil, fmt.Errorf("error marshalling json file: %w", err)
-	}
-
-	err = os.WriteFile(s.dataFile, jsonData, 0o644)
-	if err != nil {
-		return nil, fmt.Errorf("error saving json file: %w", err)
+	if err := s.put(note); err != nil {
+		return nil, err
 	}
+	s.Notes = append(s.Notes, note)
+	s.reindex(note)
 
 	return &note, nil
 }
 
 // DeleteNote searches the in memory notes marks it's position in the vector,
-// and recompiles the in memory vector. After it's changed in memory it resaves the json.
+// and moves the note to the trash. The note is kept with DeletedAt set so it can be
+// restored, and so sync can pass the deletion on to other devices.
+// It returns ErrConflict if another process changed the note since this store read it.
 func (s *Store) DeleteNote(id string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	indexToDelete := -1
-	for i, note := range s.Notes {
-		if note.ID == id {
-			indexToDelete = i
-			break
-		}
+	seen, cached := s.cachedNote(id)
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	if indexToDelete != -1 {
-		s.Notes = append(s.Notes[:indexToDelete], s.Notes[indexToDelete+1:]...)
-	} else {
+	indexToDelete := s.noteIndex(id)
+	if indexToDelete == -1 {
 		return nil
 	}
-
-	jsonData, err := json.Marshal(s.Notes)
-	if err != nil {
-		return fmt.Errorf("error saving json file: %w", err)
+	if cached && !s.Notes[indexToDelete].ModifiedAt.Equal(seen.ModifiedAt) {
+		return fmt.Errorf("%w: %s", ErrConflict, seen.Name)
 	}
 
-	err = os.WriteFile(s.dataFile, jsonData, 0o644)
-	if err != nil {
-		return fmt.Errorf("error saving json file: %w", err)
+	trashed := s.Notes[indexToDelete]
+	trashed.Version++
+	trashed.ModifiedAt = time.Now()
+	trashed.DeletedAt = trashed.ModifiedAt
+
+	if err := s.put(trashed); err != nil {
+		return err
 	}
+	s.Notes = append(s.Notes[:indexToDelete], s.Notes[indexToDelete+1:]...)
+	s.Trash = append([]Note{trashed}, s.Trash...)
+	s.reindex(trashed)
 
 	return nil
 }
 
-// UpdateNoteName takes the notes ID and a new name. and returns a changed note in memory and then resaves the JSON.
-// It returns an error if no note is found
+// UpdateNoteName takes the notes ID and a new name. and returns a changed note in memory and then saves it.
+// [[Links]] to the note in other notes are changed to the new name.
+// It returns an error if no note is found, and ErrConflict if another process changed it first.
 func (s *Store) UpdateNoteName(id string, newName string) (Note, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	newName = strings.TrimSpace(newName)
-	for i, note := range s.Notes {
-		if note.ID == id {
-			if s.Notes[i].Name == newName {
-				return s.Notes[i], nil
-			}
-
-			s.Notes[i].Name = newName
-			s.Notes[i].ModifiedAt = time.Now()
-
-			jsonData, err := json.Marshal(s.Notes)
-			if err != nil {
-				return Note{}, fmt.Errorf("error marshalling new JSON file: %w", err)
-			}
-			if err := os.WriteFile(s.dataFile, jsonData, 0o644); err != nil {
-				return Note{}, fmt.Errorf("error saving JSON file: %w", err)
-			}
-
-			return s.Notes[i], nil
-		}
+	if err := CheckName(newName); err != nil {
+		return Note{}, err
+	}
+
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
 	}
-	return Note{}, fmt.Errorf("could not find note with provided ID")
+	defer unlock()
+
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with provided ID", ErrNotFound)
+	}
+
+	if s.Notes[i].Name == newName {
+		return s.Notes[i], nil
+	}
+	if s.nameTaken(s.Notes[i].Notebook, newName, id) {
+		return Note{}, fmt.Errorf("name: %s, %w", newName, ErrNameTaken)
+	}
+
+	updated := s.Notes[i].newVersion()
+	updated.Name = newName
+
+	// Links to the old name are rewritten so they keep working
+	changed := s.relink(updated)
+	if err := s.putAll(changed); err != nil {
+		return Note{}, err
+	}
+
+	renamed, _ := s.cachedNote(id)
+	return renamed, nil
 }
 
-// UpdateNoteContent operates the same as UpdateNoteName. changes the in memory slices then writes to the JSON
-// It returns an error if no note is found
+// UpdateNoteContent operates the same as UpdateNoteName. changes the in memory slices then writes to storage
+// It returns an error if no note is found, and ErrConflict if another process changed it first.
 func (s *Store) UpdateNoteContent(id string, newContent string) (Note, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	trimmedContent := strings.TrimSpace(newContent)
 
-	for i, note := range s.Notes {
-		if note.ID == id {
-			// Only update if the content is actually different
-			if s.Notes[i].Content == trimmedContent {
-				// Return a copy of the unchanged note
-				return s.Notes[i], nil
-			}
-
-			// Modify the note in-place within the locked section
-			s.Notes[i].Content = trimmedContent
-			s.Notes[i].ModifiedAt = time.Now()
-
-			// Persist the change
-			jsonData, err := json.Marshal(s.Notes)
-			if err != nil {
-				return Note{}, fmt.Errorf("error saving json file: %w", err)
-			}
-			if err := os.WriteFile(s.dataFile, jsonData, 0o644); err != nil {
-				return Note{}, fmt.Errorf("error saving json file: %w", err)
-			}
-
-			// Return a COPY of the newly updated note
-			return s.Notes[i], nil
-		}
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
 	}
+	defer unlock()
 
-	return Note{}, fmt.Errorf("could not find note with ID: %s", id)
-}
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
 
-// FindNoteID takes the in memory notes array and a name, it iterates over the array until it matches the name.
-// It returns an error if no note is found
-func (s *Store) FindNoteID(notes []Note, name string) (string, error) {
-	trimmedName := strings.TrimSpace(name)
-	for i := range notes {
-		if notes[i].Name == trimmedName {
-			return notes[i].ID, nil
-		}
+	// Only update if the content is actually different
+	if s.Notes[i].Content == trimmedContent {
+		// Return a copy of the unchanged note
+		return s.Notes[i], nil
+	}
+
+	// Modify a copy and only swap it in once it's persisted
+	updated := s.Notes[i].newVersion()
+	updated.Content = trimmedContent
+
+	// Persist the change
+	if err := s.put(updated); err != nil {
+		return Note{}, err
 	}
+	s.Notes[i] = updated
+	s.reindex(updated)
 
-	return "", fmt.Errorf("could not find note with name: %s", trimmedName)
+	// Return a COPY of the newly updated note
+	return updated, nil
 }
 
-func (s *Store) GetNoteNames() []string {
-	notesArr, _ := s.GetNotes()
+// AppendNoteContent adds text to the end of a note on a new line. Appends don't clash
+// with each other, so unlike UpdateNoteContent it works on whatever is on disk and
+// several processes can append to the same note.
+func (s *Store) AppendNoteContent(id string, text string) (Note, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-	var noteNames []string
+	text = strings.TrimSpace(text)
 
-	for _, note := range notesArr {
-		name := note.Name
-		noteNames = append(noteNames, name)
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
 
+	i := s.noteIndex(id)
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+	if text == "" {
+		return s.Notes[i], nil
 	}
 
-	return noteNames
-}
+	updated := s.Notes[i].newVersion()
+	if updated.Content == "" {
+		updated.Content = text
+	} else {
+		updated.Content += "\n" + text
+	}
 
-// ExportNote takes a note ID, GetNoteFromID and preps and trims into a .md file to Documents.
-// It returns an error if no note is found, or if there is an error writing the new .md file.
-func (s *Store) ExportNote(id string) error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("could not find user's home directory: %w", err)
+	if err := s.put(updated); err != nil {
+		return Note{}, err
 	}
+	s.Notes[i] = updated
+	s.reindex(updated)
+
+	return updated, nil
+}
 
-	docs := filepath.Join(home, "Documents")
+// lockForUpdate takes the cross process lock, reloads and makes sure nobody else touched
+// the note since this store last saw it. It returns the note's index after the reload,
+// -1 if it doesn't exist. The caller must hold the write lock.
+func (s *Store) lockForUpdate(id string) (int, func(), error) {
+	seen, cached := s.cachedNote(id)
 
-	noteJSON, err := s.GetNoteFromID(id)
+	unlock, err := s.lockAndReload()
 	if err != nil {
-		return fmt.Errorf("could not get note with id: %s", id)
+		return -1, nil, err
 	}
 
-	prepName := strings.ReplaceAll(noteJSON.Name, " ", "_")
-	fileName := prepName + ".md"
-	filePath := filepath.Join(docs, fileName)
+	if cached {
+		if err := s.checkUnchanged(seen); err != nil {
+			unlock()
+			return -1, nil, err
+		}
+	}
 
-	data := []byte(noteJSON.Content)
+	return s.noteIndex(id), unlock, nil
+}
 
-	err = os.WriteFile(filePath, data, 0o666)
-	if err != nil {
-		return fmt.Errorf("could not export note: %w", err)
+func (s *Store) cachedNote(id string) (Note, bool) {
+	if i := s.noteIndex(id); i != -1 {
+		return s.Notes[i], true
 	}
-
-	return nil
+	return Note{}, false
 }
 
-// ExportAll takes the entire saved JSON file compiles multiple .md files and zips them.
-// It places it in Documents under biji-export.zip
-func (s *Store) ExportAll() error {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("could not find user's home directory: %w", err)
+func (s *Store) noteIndex(id string) int {
+	for i := range s.Notes {
+		if s.Notes[i].ID == id {
+			return i
+		}
 	}
+	return -1
+}
 
-	exportPath := filepath.Join(home, "Documents", "biji-export.zip")
+// FindNoteID finds the live note with a name. The name can be a path like work/todo,
+// a bare name has to be unique across notebooks.
+// It returns an error if no note is found
+func (s *Store) FindNoteID(name string) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 
-	tempDir, err := os.MkdirTemp("", "biji-export")
-	if err != nil {
-		return fmt.Errorf("error creating temp directory: %w", err)
-	}
+	return s.findNoteID(name)
+}
 
-	defer os.RemoveAll(tempDir)
+// findNoteID is FindNoteID for a caller that holds a lock.
+func (s *Store) findNoteID(name string) (string, error) {
+	trimmedName := strings.TrimSpace(name)
+	named := s.notesNamed(trimmedName)
 
-	notes, err := s.GetNotes()
-	if err != nil {
-		return fmt.Errorf("could not retrive notes: %w", err)
+	// A note in a notebook is named after the last part of its path
+	candidates := named
+	if _, last, ok := cutLast(trimmedName); ok {
+		candidates = append(s.notesNamed(last), named...)
+	}
+	for _, note := range candidates {
+		if note.Path() == trimmedName {
+			return note.ID, nil
+		}
 	}
 
-	for _, note := range notes {
-		cleanName := strings.ReplaceAll(note.Name, " ", "_") + ".md"
-		tmpFile := filepath.Join(tempDir, cleanName)
+	switch len(named) {
+	case 0:
+		return "", fmt.Errorf("%w note with name: %s", ErrNotFound, trimmedName)
+	case 1:
+		return named[0].ID, nil
+	default:
+		return "", fmt.Errorf("%s is in more than one notebook, use its path like %s", trimmedName, named[0].Path())
+	}
+}
 
-		data := []byte(note.Content)
+// indexed reports whether the backend holds the same notes as Notes, so lookups can
+// go through its indexes. The caller must hold a lock.
+func (s *Store) indexed() bool {
+	if s.stale || s.backend == nil {
+		return false
+	}
+	changed, err := s.backend.Changed()
+	return err == nil && !changed
+}
 
-		if err = os.WriteFile(tmpFile, data, 0o644); err != nil {
-			return fmt.Errorf("failed to write temp file %s: %w", cleanName, err)
+// noteByID finds a live note, through the backend when it's current. The caller must hold a lock.
+func (s *Store) noteByID(id string) (Note, bool) {
+	if s.indexed() {
+		note, found, err := s.backend.Lookup(id)
+		if err == nil {
+			return note, found && note.DeletedAt.IsZero()
 		}
+	}
 
+	return s.cachedNote(id)
+}
+
+// notesNamed returns the live notes called name in any notebook, through the backend
+// when it's current. The caller must hold a lock.
+func (s *Store) notesNamed(name string) []Note {
+	if s.indexed() {
+		notes, err := s.backend.LookupName(name)
+		if err == nil {
+			return slices.DeleteFunc(notes, func(n Note) bool { return !n.DeletedAt.IsZero() })
+		}
 	}
 
-	err = createZip(tempDir, exportPath)
-	if err != nil {
-		return fmt.Errorf("failed to create zip file: %w", err)
+	var named []Note
+	for _, note := range s.Notes {
+		if note.Name == name {
+			named = append(named, note)
+		}
 	}
+	return named
+}
 
-	fmt.Printf("Export Complete!\nCheck your ~/Documents directory.")
+func (s *Store) GetNoteNames() []string {
+	notesArr, _ := s.GetNotes()
 
-	return nil
+	var noteNames []string
+
+	for _, note := range notesArr {
+		name := note.Name
+		noteNames = append(noteNames, name)
+
+	}
+
+	return noteNames
 }
@@ -17,7 +17,7 @@ func (s *Store) cleanup() error {
 func TestAddNote(t *testing.T) {
 	store := &Store{}
 
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -58,7 +58,7 @@ func TestAddNote(t *testing.T) {
 
 func TestDeleteNote(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -86,7 +86,7 @@ func TestDeleteNote(t *testing.T) {
 
 func TestUpdateNote(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -129,7 +129,7 @@ func TestUpdateNote(t *testing.T) {
 
 func TestDeleteNote_NonExistentID(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -147,7 +147,7 @@ func TestDeleteNote_NonExistentID(t *testing.T) {
 
 func TestUpdateNoteName_NonExistentID(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -167,7 +167,7 @@ func TestUpdateNoteName_NonExistentID(t *testing.T) {
 
 func TestUpdateNoteContent_NonExistentID(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -187,7 +187,7 @@ func TestUpdateNoteContent_NonExistentID(t *testing.T) {
 
 func TestConcurrentAddNotes(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -219,7 +219,7 @@ func TestConcurrentAddNotes(t *testing.T) {
 
 func TestConcurrentDeleteNotes(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
@@ -250,7 +250,7 @@ func TestConcurrentDeleteNotes(t *testing.T) {
 
 func TestConcurrentUpdates(t *testing.T) {
 	store := &Store{}
-	err := store.Init()
+	err := store.InitAt(t.TempDir())
 	if err != nil {
 		t.Fatalf("Failed to create test store: %v", err)
 	}
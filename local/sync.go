@@ -0,0 +1,175 @@
+package local
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// LastSynced returns the most recent LastSync of any note, which is the point the
+// server needs to send changes from. A zero time means the store has never synced.
+func (s *Store) LastSynced() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var last time.Time
+	for _, note := range slices.Concat(s.Notes, s.Trash) {
+		if note.LastSync.After(last) {
+			last = note.LastSync
+		}
+	}
+
+	return last
+}
+
+// PendingSync returns copies of the notes that were modified after they were last synced.
+// Notes deleted since then are included so the deletion reaches other devices.
+func (s *Store) PendingSync() []Note {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var pending []Note
+	for _, note := range slices.Concat(s.Notes, s.Trash) {
+		if note.ModifiedAt.After(note.LastSync) {
+			pending = append(pending, note)
+		}
+	}
+
+	return pending
+}
+
+// ForgetSync clears every note's LastSync, for when the device is unlinked. Another
+// server hasn't seen any of the notes, so the next sync has to push and pull them all.
+func (s *Store) ForgetSync() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var changed []Note
+	for _, note := range slices.Concat(s.Notes, s.Trash) {
+		if !note.LastSync.IsZero() {
+			note.LastSync = time.Time{}
+			changed = append(changed, note)
+		}
+	}
+
+	return s.putAll(changed)
+}
+
+// MergeRemote applies the outcome of a sync. Every pushed note that hasn't been edited
+// since it was pushed is stamped with syncedAt, and the remote notes replace their local copies
+// or get added if they are new.
+func (s *Store) MergeRemote(pushed, remote []Note, syncedAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	pushedAt := make(map[string]time.Time, len(pushed))
+	for _, note := range pushed {
+		pushedAt[note.ID] = note.ModifiedAt
+	}
+
+	var changed []Note
+	for _, note := range slices.Concat(s.Notes, s.Trash) {
+		modifiedAt, ok := pushedAt[note.ID]
+		if ok && note.ModifiedAt.Equal(modifiedAt) {
+			note.LastSync = syncedAt
+			changed = append(changed, note)
+		}
+	}
+
+	for _, remoteNote := range remote {
+		remoteNote.LastSync = syncedAt
+		changed = append(changed, remoteNote)
+	}
+
+	return s.putAll(changed)
+}
+
+// UpsertNotes stores notes as they are, replacing any note with the same ID.
+// Unlike MergeRemote it leaves LastSync alone, so the notes are pushed on the next sync
+// if they were modified after it.
+func (s *Store) UpsertNotes(notes []Note) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return s.putAll(notes)
+}
+
+// Dir returns the directory the store keeps its data in.
+func (s *Store) Dir() string {
+	return filepath.Dir(s.dataFile)
+}
+
+// putAll saves the notes to storage and then swaps them into memory, replacing the note
+// with the same ID or adding it. Notes with DeletedAt set go to the trash. Notes from another
+// device can share a name with a different local note, those get a numbered suffix so names
+// stay unique, saved as a new version so the next sync pushes it. The caller must hold the
+// write lock.
+func (s *Store) putAll(notes []Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	notes = slices.Clone(notes)
+	for i := range notes {
+		notes[i].History = nil
+		if !notes[i].DeletedAt.IsZero() {
+			continue
+		}
+
+		// The rename is a change like any other, other devices have to hear of it
+		if name := s.uniqueName(notes[i]); name != notes[i].Name {
+			notes[i].Name = name
+			notes[i].Version++
+			notes[i].ModifiedAt = time.Now()
+			if !notes[i].ModifiedAt.After(notes[i].LastSync) {
+				notes[i].ModifiedAt = notes[i].LastSync.Add(time.Millisecond)
+			}
+		}
+	}
+
+	if err := s.put(notes...); err != nil {
+		return err
+	}
+
+	for _, note := range notes {
+		s.Notes = slices.DeleteFunc(s.Notes, func(n Note) bool { return n.ID == note.ID })
+		s.Trash = slices.DeleteFunc(s.Trash, func(n Note) bool { return n.ID == note.ID })
+
+		if note.DeletedAt.IsZero() {
+			s.Notes = append(s.Notes, note)
+		} else {
+			s.Trash = append(s.Trash, note)
+		}
+	}
+	s.reindex(notes...)
+
+	return nil
+}
+
+func (s *Store) uniqueName(note Note) string {
+	candidate := note.Name
+	for n := 2; s.nameTaken(note.Notebook, candidate, note.ID); n++ {
+		candidate = fmt.Sprintf("%s (%d)", note.Name, n)
+	}
+
+	return candidate
+}
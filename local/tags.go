@@ -0,0 +1,231 @@
+package local
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AllTags returns the note's tags together with the #hashtags written in its content,
+// normalized, sorted and without duplicates.
+func (n Note) AllTags() []string {
+	tags := normalizeTags(slices.Concat(n.Tags, ParseHashtags(n.Content)))
+
+	slices.Sort(tags)
+	return slices.Compact(slices.DeleteFunc(tags, func(tag string) bool { return tag == "" }))
+}
+
+// HasTag reports whether the note is tagged with tag, either directly or with a #hashtag.
+func (n Note) HasTag(tag string) bool {
+	return slices.Contains(n.AllTags(), NormalizeTag(tag))
+}
+
+// NormalizeTag lower cases a tag and drops a leading #, so #Work and work are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = NormalizeTag(tag)
+	}
+	return normalized
+}
+
+// ParseHashtags finds the #hashtags in text. A hashtag starts with a letter and runs over
+// letters, digits, -, _ and /. Markdown headings, URL fragments and ## are not tags.
+func ParseHashtags(text string) []string {
+	var tags []string
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || i+1 >= len(runes) || !unicode.IsLetter(runes[i+1]) {
+			continue
+		}
+		if i > 0 && !startsTag(runes[i-1]) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+		tag := strings.TrimRight(string(runes[i+1:end]), "-/")
+		tags = append(tags, strings.ToLower(tag))
+		i = end - 1
+	}
+
+	return tags
+}
+
+func startsTag(prev rune) bool {
+	return unicode.IsSpace(prev) || strings.ContainsRune("([{,;", prev)
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '/'
+}
+
+func validTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag is empty")
+	}
+	if strings.ContainsFunc(tag, func(r rune) bool { return !isTagRune(r) }) {
+		return fmt.Errorf("tag: %s, can only contain letters, digits, -, _ and /", tag)
+	}
+	return nil
+}
+
+// AddTags tags a note. Tags are normalized and ones it already has are skipped.
+// It returns ErrConflict if another process changed the note first.
+func (s *Store) AddTags(id string, tags ...string) (Note, error) {
+	tags = normalizeTags(tags)
+	for _, tag := range tags {
+		if err := validTag(tag); err != nil {
+			return Note{}, err
+		}
+	}
+
+	return s.updateTags(id, func(current []string) []string {
+		for _, tag := range tags {
+			if !slices.Contains(current, tag) {
+				current = append(current, tag)
+			}
+		}
+		return current
+	})
+}
+
+// RemoveTags takes tags off a note. A #hashtag in the content stays until the content changes.
+// It returns ErrConflict if another process changed the note first.
+func (s *Store) RemoveTags(id string, tags ...string) (Note, error) {
+	tags = normalizeTags(tags)
+
+	return s.updateTags(id, func(current []string) []string {
+		return slices.DeleteFunc(current, func(tag string) bool { return slices.Contains(tags, tag) })
+	})
+}
+
+func (s *Store) updateTags(id string, change func([]string) []string) (Note, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, unlock, err := s.lockForUpdate(id)
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
+
+	if i == -1 {
+		return Note{}, fmt.Errorf("%w note with ID: %s", ErrNotFound, id)
+	}
+
+	tags := change(slices.Clone(s.Notes[i].Tags))
+	slices.Sort(tags)
+	if slices.Equal(tags, s.Notes[i].Tags) {
+		return s.Notes[i], nil
+	}
+
+	updated := s.Notes[i].newVersion()
+	updated.Tags = tags
+
+	if err := s.put(updated); err != nil {
+		return Note{}, err
+	}
+	s.Notes[i] = updated
+	s.reindex(updated)
+
+	return updated, nil
+}
+
+// taggedContent is the content with a closing line of #hashtags for the tags that
+// aren't written in it already, so tags survive an export as plain markdown.
+func (n Note) taggedContent() string {
+	inline := ParseHashtags(n.Content)
+
+	var missing []string
+	for _, tag := range n.Tags {
+		if !slices.Contains(inline, tag) {
+			missing = append(missing, "#"+tag)
+		}
+	}
+	if len(missing) == 0 {
+		return n.Content
+	}
+
+	return strings.TrimRight(n.Content, "\n") + "\n\n" + strings.Join(missing, " ") + "\n"
+}
+
+// splitTagLine undoes taggedContent, a closing line of nothing but #hashtags after a
+// blank line is taken off the content and returned as tags.
+func splitTagLine(content string) (string, []string) {
+	i := strings.LastIndex(content, "\n\n")
+	if i == -1 {
+		return content, nil
+	}
+
+	line := strings.TrimSpace(content[i:])
+	tags := ParseHashtags(line)
+	if len(tags) == 0 || len(tags) != len(strings.Fields(line)) {
+		return content, nil
+	}
+	for _, field := range strings.Fields(line) {
+		if !strings.HasPrefix(field, "#") || validTag(NormalizeTag(field)) != nil {
+			return content, nil
+		}
+	}
+
+	return strings.TrimSpace(content[:i]), tags
+}
+
+// NotesWithTag returns the notes tagged with tag, newest first.
+func (s *Store) NotesWithTag(tag string) []Note {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	tag = NormalizeTag(tag)
+
+	var notes []Note
+	for _, note := range s.Notes {
+		if note.HasTag(tag) {
+			notes = append(notes, note)
+		}
+	}
+
+	return notes
+}
+
+// TagCount is a tag and how many notes have it.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// Tags lists every tag in use, most used first.
+func (s *Store) Tags() []TagCount {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	counts := make(map[string]int)
+	for _, note := range s.Notes {
+		for _, tag := range note.AllTags() {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{tag, count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	return tags
+}
@@ -0,0 +1,106 @@
+package local
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseHashtags(t *testing.T) {
+	text := "# Heading\nPlan the #trip with #Work/travel, see http://x.com/#anchor and (#todo) ##not #2024"
+
+	got := ParseHashtags(text)
+	want := []string{"trip", "work/travel", "todo"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestTags(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	note, err := store.AddNote("plans", "book it #travel")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.AddNote("other", "nothing here"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	tagged, err := store.AddTags(note.ID, "#Work", "urgent", "work")
+	if err != nil {
+		t.Fatalf("Failed to add tags: %v", err)
+	}
+	if !slices.Equal(tagged.Tags, []string{"urgent", "work"}) {
+		t.Errorf("Expected normalized tags, got %v", tagged.Tags)
+	}
+	if !slices.Equal(tagged.AllTags(), []string{"travel", "urgent", "work"}) {
+		t.Errorf("Expected hashtags included, got %v", tagged.AllTags())
+	}
+	if tagged.Version != 2 {
+		t.Errorf("Expected tagging to make a new version, got %d", tagged.Version)
+	}
+
+	if _, err := store.AddTags(note.ID, "two words"); err == nil {
+		t.Error("Expected an error for a tag with a space")
+	}
+
+	if notes := store.NotesWithTag("TRAVEL"); len(notes) != 1 || notes[0].ID != note.ID {
+		t.Errorf("Expected the note for its hashtag, got %v", notes)
+	}
+
+	untagged, err := store.RemoveTags(note.ID, "urgent")
+	if err != nil {
+		t.Fatalf("Failed to remove tags: %v", err)
+	}
+	if untagged.HasTag("urgent") || !untagged.HasTag("work") {
+		t.Errorf("Expected only urgent removed, got %v", untagged.Tags)
+	}
+
+	tags := store.Tags()
+	if len(tags) != 2 || tags[0].Tag != "travel" || tags[0].Count != 1 {
+		t.Errorf("Unexpected tag counts: %v", tags)
+	}
+
+	// Tags live in the note, so they persist with it
+	reopened := &Store{}
+	if err := reopened.InitAt(store.Dir()); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if notes := reopened.NotesWithTag("work"); len(notes) != 1 {
+		t.Errorf("Expected the tag to persist, got %v", notes)
+	}
+}
+
+func TestExportNote_KeepsTags(t *testing.T) {
+	dir := t.TempDir()
+
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	note, err := store.AddNote("tagged", "see #inline")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := store.AddTags(note.ID, "inline", "extra"); err != nil {
+		t.Fatalf("Failed to add tags: %v", err)
+	}
+
+	filePath, err := store.ExportNote(note.ID, dir, FormatMarkdown)
+	if err != nil {
+		t.Fatalf("Failed to export note: %v", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if !strings.Contains(string(data), "\ntags: [extra, inline]\n") || !strings.HasSuffix(string(data), "---\n\nsee #inline\n") {
+		t.Errorf("Expected the tags in the front matter, got %q", data)
+	}
+}
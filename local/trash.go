@@ -0,0 +1,133 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultTrashDays is how long deleted notes stay in the trash unless BIJI_TRASH_DAYS says otherwise.
+const defaultTrashDays = 30
+
+// trashRetention reads BIJI_TRASH_DAYS, 0 or less turns automatic purging off.
+func trashRetention() time.Duration {
+	days := defaultTrashDays
+	if env := os.Getenv("BIJI_TRASH_DAYS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil {
+			days = parsed
+		}
+	}
+
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetTrashedNote returns a note from the trash by ID.
+func (s *Store) GetTrashedNote(id string) (Note, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, note := range s.Trash {
+		if note.ID == id {
+			return note, nil
+		}
+	}
+
+	return Note{}, fmt.Errorf("%w note in trash with ID: %s", ErrNotFound, id)
+}
+
+// RestoreNote takes a note out of the trash. If a live note took its name in the
+// meantime the restored note gets a numbered suffix.
+func (s *Store) RestoreNote(id string) (Note, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return Note{}, err
+	}
+	defer unlock()
+
+	for _, note := range s.Trash {
+		if note.ID != id {
+			continue
+		}
+
+		note.Version++
+		note.ModifiedAt = time.Now()
+		note.DeletedAt = time.Time{}
+
+		if err := s.putAll([]Note{note}); err != nil {
+			return Note{}, err
+		}
+
+		restored, _ := s.cachedNote(id)
+		return restored, nil
+	}
+
+	return Note{}, fmt.Errorf("%w note in trash with ID: %s", ErrNotFound, id)
+}
+
+// EmptyTrash permanently deletes every note in the trash and returns how many went.
+// Notes whose deletion hasn't synced yet stay until it has, see deletionSynced.
+func (s *Store) EmptyTrash() (int, error) {
+	return s.purge(func(Note) bool { return true })
+}
+
+// PurgeTrash permanently deletes notes that have been in the trash longer than age,
+// as long as their deletion has synced.
+func (s *Store) PurgeTrash(age time.Duration) (int, error) {
+	cutoff := time.Now().Add(-age)
+	return s.purge(func(note Note) bool { return note.DeletedAt.Before(cutoff) })
+}
+
+// deletionSynced reports whether a trashed note can go for good. Once it's gone there's
+// nothing left to push, so a deletion the server hasn't seen would come back with the
+// next sync. A note that never reached the server can't come back.
+func deletionSynced(note Note) bool {
+	return note.LastSync.IsZero() || !note.ModifiedAt.After(note.LastSync)
+}
+
+func (s *Store) purge(match func(Note) bool) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Init purges old notes every time biji starts and usually none are old enough,
+	// so a plain look at the trash saves taking the file lock for nothing
+	if !s.trashHas(match) {
+		return 0, nil
+	}
+
+	unlock, err := s.lockAndReload()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var kept []Note
+	var purged []string
+	for _, note := range s.Trash {
+		if match(note) && deletionSynced(note) {
+			purged = append(purged, note.ID)
+		} else {
+			kept = append(kept, note)
+		}
+	}
+
+	if err := s.backend.Remove(purged...); err != nil {
+		return 0, err
+	}
+	s.removeHistory(purged...)
+	s.Trash = kept
+
+	return len(purged), nil
+}
+
+func (s *Store) trashHas(match func(Note) bool) bool {
+	for _, note := range s.Trash {
+		if match(note) && deletionSynced(note) {
+			return true
+		}
+	}
+	return false
+}
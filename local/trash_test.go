@@ -0,0 +1,106 @@
+package local
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteNote_MovesToTrash(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	note, err := store.AddNote("old idea", "maybe later")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if err := store.DeleteNote(note.ID); err != nil {
+		t.Fatalf("Failed to delete note: %v", err)
+	}
+
+	if len(store.Notes) != 0 || len(store.Trash) != 1 {
+		t.Fatalf("Expected the note in the trash, got %d notes and %d trashed", len(store.Notes), len(store.Trash))
+	}
+	if store.Trash[0].DeletedAt.IsZero() {
+		t.Error("Expected the trashed note to have DeletedAt set")
+	}
+
+	// A new note can take the name while the old one sits in the trash
+	if _, err := store.AddNote("old idea", "a fresh take"); err != nil {
+		t.Fatalf("Failed to reuse name of trashed note: %v", err)
+	}
+
+	restored, err := store.RestoreNote(note.ID)
+	if err != nil {
+		t.Fatalf("Failed to restore note: %v", err)
+	}
+	if restored.Name != "old idea (2)" || restored.Content != "maybe later" {
+		t.Errorf("Expected restored note renamed around the new one, got %+v", restored)
+	}
+	if len(store.Notes) != 2 || len(store.Trash) != 0 {
+		t.Errorf("Expected 2 notes and an empty trash, got %d and %d", len(store.Notes), len(store.Trash))
+	}
+
+	reopened := &Store{}
+	if err := reopened.InitAt(store.Dir()); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	if len(reopened.Notes) != 2 || len(reopened.Trash) != 0 {
+		t.Errorf("Expected restore to persist, got %d notes and %d trashed", len(reopened.Notes), len(reopened.Trash))
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	store := &Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		note, err := store.AddNote(name, "")
+		if err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+		if err := store.DeleteNote(note.ID); err != nil {
+			t.Fatalf("Failed to delete note: %v", err)
+		}
+	}
+
+	// Backdate one note so only it is past the retention
+	old := store.Trash[0]
+	old.DeletedAt = time.Now().Add(-48 * time.Hour)
+	if err := store.backend.Put(old); err != nil {
+		t.Fatalf("Failed to backdate note: %v", err)
+	}
+	if err := store.load(); err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+
+	n, err := store.PurgeTrash(24 * time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to purge trash: %v", err)
+	}
+	if n != 1 || len(store.Trash) != 2 {
+		t.Fatalf("Expected 1 note purged and 2 left, got %d and %d", n, len(store.Trash))
+	}
+	if _, err := store.GetTrashedNote(old.ID); err == nil {
+		t.Error("Expected the old note to be gone")
+	}
+
+	n, err = store.EmptyTrash()
+	if err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if n != 2 || len(store.Trash) != 0 {
+		t.Errorf("Expected 2 notes emptied, got %d with %d left", n, len(store.Trash))
+	}
+
+	loaded, err := store.backend.Load()
+	if err != nil {
+		t.Fatalf("Failed to load backend: %v", err)
+	}
+	if len(loaded) != 0 {
+		t.Errorf("Expected nothing left on disk, got %d notes", len(loaded))
+	}
+}
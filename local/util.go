@@ -1,57 +1,72 @@
 package local
 
 import (
-	"archive/zip"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 )
 
-func createZip(source string, target string) error {
-	zipFile, err := os.Create(target)
+// WriteFileAtomic writes data to a temp file next to path, syncs it to disk and renames it
+// over path. A crash leaves either the old file or the new one, never a truncated mix.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		return fmt.Errorf("error creating target directory %w", err)
+		return fmt.Errorf("error creating temp file: %w", err)
 	}
+	tmpPath := tmp.Name()
 
-	defer zipFile.Close()
-
-	archive := zip.NewWriter(zipFile)
-	defer archive.Close()
+	// Only cleans up when something below fails, after the rename there is nothing to remove
+	defer os.Remove(tmpPath)
 
-	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error setting temp file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing temp file: %w", err)
+	}
 
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
-			return err
-		}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("error replacing %s: %w", filepath.Base(path), err)
+	}
 
-		header.Name = filepath.Base(path)
-		header.Method = zip.Deflate
+	// Make the rename itself durable, not every OS lets a directory be synced
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
 
-		writer, err := archive.CreateHeader(header)
-		if err != nil {
-			return err
-		}
-		file, err := os.Open(path)
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		if info.Size() == 0 {
-			file.Close()
-			return nil
-		}
+// copyFile copies src to dst, replacing dst.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
 
-		_, err = io.Copy(writer, file)
-		file.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
 
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
 		return err
-	})
+	}
+
+	return out.Close()
 }
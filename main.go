@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"os"
 
 	"github.com/dallas1295/biji/cmd"
 	"github.com/dallas1295/biji/local"
@@ -9,9 +9,8 @@ import (
 
 func main() {
 	s := &local.Store{}
-	if err := s.Init(); err != nil {
-		log.Fatalf("failed to initialize store: %v:", err)
-	}
+	code := cmd.Execute(s)
+	s.Close()
 
-	cmd.Execute(s)
+	os.Exit(code)
 }
@@ -0,0 +1,373 @@
+// Package markdown reads the Markdown notes are written in and renders it as HTML for
+// exports. It only knows what notes commonly use, plus [[links]] between notes.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// mdKind is the kind of a Markdown block. Only what notes commonly use is understood,
+// anything else reads as a paragraph.
+type mdKind int
+
+const (
+	mdParagraph mdKind = iota
+	mdHeading
+	mdList
+	mdQuote
+	mdCode
+	mdRule
+	mdTable
+)
+
+// mdBlock is one block of a note's Markdown. lines holds the text of a paragraph, quote
+// or code block, or the items of a list. A table's cells are in rows, the header first.
+type mdBlock struct {
+	kind    mdKind
+	level   int // heading level
+	ordered bool
+	lang    string
+	lines   []string
+	rows    [][]string
+	align   []string // per table column: left, center, right or empty
+}
+
+// parseMarkdown splits content into blocks.
+func parseMarkdown(content string) []mdBlock {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	var blocks []mdBlock
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case isFence(trimmed):
+			fence := trimmed[:3]
+			block := mdBlock{kind: mdCode, lang: strings.TrimSpace(trimmed[3:])}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				block.lines = append(block.lines, lines[i])
+			}
+			i++ // the closing fence
+			blocks = append(blocks, block)
+
+		case headingLevel(trimmed) > 0:
+			level := headingLevel(trimmed)
+			blocks = append(blocks, mdBlock{kind: mdHeading, level: level, lines: []string{strings.TrimSpace(trimmed[level:])}})
+			i++
+
+		case isRule(trimmed):
+			blocks = append(blocks, mdBlock{kind: mdRule})
+			i++
+
+		case isTable(lines[i:]):
+			block := mdBlock{kind: mdTable, rows: [][]string{tableCells(trimmed)}}
+			for _, cell := range tableCells(strings.TrimSpace(lines[i+1])) {
+				block.align = append(block.align, cellAlign(cell))
+			}
+			for i += 2; i < len(lines) && strings.Contains(lines[i], "|"); i++ {
+				block.rows = append(block.rows, tableCells(strings.TrimSpace(lines[i])))
+			}
+			blocks = append(blocks, block)
+
+		case strings.HasPrefix(trimmed, ">"):
+			block := mdBlock{kind: mdQuote}
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				line := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				block.lines = append(block.lines, strings.TrimPrefix(line, " "))
+			}
+			blocks = append(blocks, block)
+
+		default:
+			if _, ordered, ok := listItem(trimmed); ok {
+				block := mdBlock{kind: mdList, ordered: ordered}
+				for i < len(lines) {
+					line := strings.TrimSpace(lines[i])
+					if item, itemOrdered, ok := listItem(line); ok && itemOrdered == ordered {
+						block.lines = append(block.lines, item)
+					} else if line != "" && !blockStart(line) && len(block.lines) > 0 {
+						// A line that carries on the item above
+						block.lines[len(block.lines)-1] += "\n" + line
+					} else {
+						break
+					}
+					i++
+				}
+				blocks = append(blocks, block)
+				continue
+			}
+
+			block := mdBlock{kind: mdParagraph}
+			for ; i < len(lines); i++ {
+				line := strings.TrimSpace(lines[i])
+				if line == "" || (len(block.lines) > 0 && blockStart(line)) {
+					break
+				}
+				block.lines = append(block.lines, line)
+			}
+			blocks = append(blocks, block)
+		}
+	}
+
+	return blocks
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")
+}
+
+// headingLevel returns the level of an ATX heading like ## Title, 0 when line isn't one.
+// A #hashtag isn't a heading, the #s have to be followed by a space.
+func headingLevel(line string) int {
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+func isRule(line string) bool {
+	line = strings.ReplaceAll(line, " ", "")
+	return len(line) >= 3 && strings.Count(line, line[:1]) == len(line) && strings.Contains("-*_", line[:1])
+}
+
+// listItem returns the text of a list item line, and whether the list is numbered.
+func listItem(line string) (string, bool, bool) {
+	for _, bullet := range []string{"- ", "* ", "+ "} {
+		if item, ok := strings.CutPrefix(line, bullet); ok {
+			return item, false, true
+		}
+	}
+
+	digits := len(line) - len(strings.TrimLeft(line, "0123456789"))
+	if digits > 0 && digits < 10 && len(line) > digits+1 && strings.Contains(".)", line[digits:digits+1]) && line[digits+1] == ' ' {
+		return line[digits+2:], true, true
+	}
+
+	return "", false, false
+}
+
+// isTable reports whether lines start with a table, a header row with a delimiter row
+// of as many cells under it.
+func isTable(lines []string) bool {
+	if len(lines) < 2 || !strings.Contains(lines[0], "|") {
+		return false
+	}
+	delimiter := strings.TrimSpace(lines[1])
+	return tableDelimiter.MatchString(delimiter) &&
+		len(tableCells(strings.TrimSpace(lines[0]))) == len(tableCells(delimiter))
+}
+
+// tableDelimiter matches the row under a table's header, like |---|:--:|
+var tableDelimiter = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+
+// tableCells splits a table row into its cells, \| being a | inside a cell.
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// cellAlign reads a column's alignment from its delimiter cell, :-- :-: or --:.
+func cellAlign(delimiter string) string {
+	switch left, right := strings.HasPrefix(delimiter, ":"), strings.HasSuffix(delimiter, ":"); {
+	case left && right:
+		return "center"
+	case right:
+		return "right"
+	case left:
+		return "left"
+	}
+	return ""
+}
+
+func blockStart(line string) bool {
+	_, _, item := listItem(line)
+	return item || isFence(line) || headingLevel(line) > 0 || isRule(line) || strings.HasPrefix(line, ">")
+}
+
+// HTML renders a note's Markdown as HTML. link maps a [[link]] target to the href of
+// the note it leads to, "" leaves the link as text. A nil link never links.
+func HTML(content string, link func(target string) string) string {
+	var b strings.Builder
+
+	for _, block := range parseMarkdown(content) {
+		switch block.kind {
+		case mdHeading:
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", block.level, inlineHTML(block.lines[0], link), block.level)
+		case mdParagraph:
+			fmt.Fprintf(&b, "<p>%s</p>\n", linesHTML(block.lines, link))
+		case mdQuote:
+			fmt.Fprintf(&b, "<blockquote><p>%s</p></blockquote>\n", linesHTML(block.lines, link))
+		case mdCode:
+			class := ""
+			if block.lang != "" {
+				class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(block.lang))
+			}
+			fmt.Fprintf(&b, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(block.lines, "\n")))
+		case mdRule:
+			b.WriteString("<hr>\n")
+		case mdList:
+			tag := "ul"
+			if block.ordered {
+				tag = "ol"
+			}
+			fmt.Fprintf(&b, "<%s>\n", tag)
+			for _, item := range block.lines {
+				fmt.Fprintf(&b, "<li>%s</li>\n", taskHTML(item, link))
+			}
+			fmt.Fprintf(&b, "</%s>\n", tag)
+		case mdTable:
+			b.WriteString("<table>\n")
+			for r, row := range block.rows {
+				cell := "td"
+				if r == 0 {
+					cell = "th"
+					b.WriteString("<thead>\n")
+				}
+				b.WriteString("<tr>")
+				for c := range block.align {
+					style := ""
+					if block.align[c] != "" {
+						style = fmt.Sprintf(` style="text-align: %s"`, block.align[c])
+					}
+					text := ""
+					if c < len(row) {
+						text = inlineHTML(row[c], link)
+					}
+					fmt.Fprintf(&b, "<%s%s>%s</%s>", cell, style, text, cell)
+				}
+				b.WriteString("</tr>\n")
+				if r == 0 {
+					b.WriteString("</thead>\n<tbody>\n")
+				}
+			}
+			b.WriteString("</tbody>\n</table>\n")
+		}
+	}
+
+	return b.String()
+}
+
+// linesHTML keeps the line breaks of a paragraph, notes are written line by line.
+func linesHTML(lines []string, link func(string) string) string {
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = inlineHTML(line, link)
+	}
+	return strings.Join(rendered, "<br>\n")
+}
+
+// taskHTML renders a list item, showing - [ ] and - [x] tasks as boxes.
+func taskHTML(item string, link func(string) string) string {
+	if rest, ok := strings.CutPrefix(item, "[ ] "); ok {
+		return "&#9744; " + linesHTML(strings.Split(rest, "\n"), link)
+	}
+	if rest, ok := strings.CutPrefix(strings.Replace(item, "[X] ", "[x] ", 1), "[x] "); ok {
+		return "&#9745; " + linesHTML(strings.Split(rest, "\n"), link)
+	}
+	return linesHTML(strings.Split(item, "\n"), link)
+}
+
+var (
+	// inlinePattern matches the spans that can't hold emphasis: `code`, [[links]] and
+	// Markdown links and images
+	inlinePattern = regexp.MustCompile("`[^`\n]+`" + `|!?\[\[[^\[\]\n]+\]\]|!?\[[^\[\]\n]*\]\([^()\s]+\)`)
+	boldPattern   = regexp.MustCompile(`\*\*([^*\n]+)\*\*|__([^_\n]+)__`)
+	emPattern     = regexp.MustCompile(`\*([^*\n]+)\*|\b_([^_\n]+)_\b`)
+	mdLinkPattern = regexp.MustCompile(`^(!?)\[([^\[\]\n]*)\]\(([^()\s]+)\)$`)
+	// wikiLinkPattern matches [[Target]] and [[Target|shown text]], the links between notes
+	wikiLinkPattern = regexp.MustCompile(`^\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]*))?\]\]$`)
+)
+
+func inlineHTML(text string, link func(string) string) string {
+	var b strings.Builder
+
+	last := 0
+	for _, m := range inlinePattern.FindAllStringIndex(text, -1) {
+		b.WriteString(emphasisHTML(text[last:m[0]]))
+		b.WriteString(spanHTML(text[m[0]:m[1]], link))
+		last = m[1]
+	}
+	b.WriteString(emphasisHTML(text[last:]))
+
+	return b.String()
+}
+
+func emphasisHTML(text string) string {
+	text = html.EscapeString(text)
+	text = boldPattern.ReplaceAllString(text, "<strong>$1$2</strong>")
+	return emPattern.ReplaceAllString(text, "<em>$1$2</em>")
+}
+
+func spanHTML(span string, link func(string) string) string {
+	switch {
+	case strings.HasPrefix(span, "`"):
+		return "<code>" + html.EscapeString(strings.Trim(span, "`")) + "</code>"
+
+	case strings.HasPrefix(span, "[["):
+		m := wikiLinkPattern.FindStringSubmatch(span)
+		target := ""
+		if m != nil {
+			target = strings.TrimSpace(m[1])
+		}
+		if target == "" {
+			return html.EscapeString(span)
+		}
+		shown := m[2]
+		if shown == "" {
+			shown = target
+		}
+		if link != nil {
+			if href := link(target); href != "" {
+				return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(shown))
+			}
+		}
+		return `<span class="link">` + html.EscapeString(shown) + "</span>"
+	}
+
+	m := mdLinkPattern.FindStringSubmatch(span)
+	if m == nil {
+		// ![[embeds]] and anything else stay as they were written
+		return html.EscapeString(span)
+	}
+	href := html.EscapeString(safeURL(m[3]))
+	if m[1] == "!" {
+		return fmt.Sprintf(`<img src="%s" alt="%s">`, href, html.EscapeString(m[2]))
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, href, emphasisHTML(m[2]))
+}
+
+// safeURL drops links that would run script when clicked.
+func safeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "#"
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto", "ftp":
+		return raw
+	}
+	return "#"
+}
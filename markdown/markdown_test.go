@@ -0,0 +1,32 @@
+package markdown
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		markdown string
+		want     string
+	}{
+		{"## Title", "<h2>Title</h2>\n"},
+		{"#tag not a heading", "<p>#tag not a heading</p>\n"},
+		{"one\ntwo", "<p>one<br>\ntwo</p>\n"},
+		{"- a\n- [x] b\n\n1. c", "<ul>\n<li>a</li>\n<li>&#9745; b</li>\n</ul>\n<ol>\n<li>c</li>\n</ol>\n"},
+		{"```go\nif a < b {}\n```", "<pre><code class=\"language-go\">if a &lt; b {}</code></pre>\n"},
+		{"> quoted", "<blockquote><p>quoted</p></blockquote>\n"},
+		{"---", "<hr>\n"},
+		{"*em* and snake_case_name", "<p><em>em</em> and snake_case_name</p>\n"},
+		{"`**raw**`", "<p><code>**raw**</code></p>\n"},
+		{"[click](javascript:alert(1))", "<p>[click](javascript:alert(1))</p>\n"},
+		{"[x](javascript:void)", "<p><a href=\"#\">x</a></p>\n"},
+		{"[site](https://example.com?a=1&b=2)", "<p><a href=\"https://example.com?a=1&amp;b=2\">site</a></p>\n"},
+		{"| a | b |\n|:--|--:|\n| 1 | 2 |", "<table>\n<thead>\n<tr><th style=\"text-align: left\">a</th><th style=\"text-align: right\">b</th></tr>\n</thead>\n<tbody>\n<tr><td style=\"text-align: left\">1</td><td style=\"text-align: right\">2</td></tr>\n</tbody>\n</table>\n"},
+		{"[[ideas|the ideas]]", "<p><span class=\"link\">the ideas</span></p>\n"},
+		{"| a | b |\n|---|\n| 1 | 2 |", "<p>| a | b |<br>\n|---|<br>\n| 1 | 2 |</p>\n"},
+	}
+
+	for _, tt := range tests {
+		if got := HTML(tt.markdown, nil); got != tt.want {
+			t.Errorf("HTML(%q) = %q, want %q", tt.markdown, got, tt.want)
+		}
+	}
+}
@@ -15,10 +15,15 @@ type SyncRequest struct {
 	LastSync string       `json:"lastSync"`
 }
 
-func (s *Server) SyncHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO implement real sync logic
+type SyncResponse struct {
+	Notes    []local.Note `json:"notes"`
+	SyncTime string       `json:"syncTime"`
+}
 
-	if r.Method != "POST" {
+// SyncHandler merges the notes a client changed since its last sync into the
+// user's stored notes and answers with the server side changes the client is missing.
+func (s *Server) SyncHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -29,18 +34,120 @@ func (s *Server) SyncHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Notes    []local.Note `json:"notes"`
-		LastSync string       `json:"lastSync"`
-	}
-
+	var req SyncRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
+	// An empty LastSync means the client has never synced and needs everything
+	var lastSync time.Time
+	if req.LastSync != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, req.LastSync)
+		if err != nil {
+			http.Error(w, "Invalid lastSync timestamp", http.StatusBadRequest)
+			return
+		}
+		lastSync = parsed
+	}
+
+	s.mu.RLock()
+	user, userExists := s.users[syncCode]
+	userLock, lockExists := s.userLocks[syncCode]
+	s.mu.RUnlock()
+
+	if !userExists || !lockExists {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+
+	syncTime := time.Now().UTC()
+
+	userLock.Lock()
+	merged, missing := mergeNotes(user.Notes, req.Notes, lastSync, syncTime)
+	user.Notes = merged
+	userLock.Unlock()
+
+	if err := s.saveUserToDisk(syncCode); err != nil {
+		http.Error(w, "Failed to save notes", http.StatusInternalServerError)
+		return
+	}
+
+	res := SyncResponse{
+		Notes:    missing,
+		SyncTime: syncTime.Format(time.RFC3339Nano),
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(req)
+	json.NewEncoder(w).Encode(res)
+}
+
+// mergeNotes is a three way merge between the server's notes, the client's notes
+// and the common base at lastSync. A side counts as changed when it was touched after lastSync,
+// for the server that's the LastSync stamp it gave the note, for the client it's ModifiedAt.
+// When both sides changed the server copy is kept and sent back so the client's
+// conflict strategy can settle it and push the result.
+// It returns the merged notes and the notes the client needs to bring itself up to date.
+func mergeNotes(serverNotes, clientNotes []local.Note, lastSync, syncTime time.Time) ([]local.Note, []local.Note) {
+	merged := make([]local.Note, len(serverNotes))
+	copy(merged, serverNotes)
+
+	index := make(map[string]int, len(merged))
+	for i, note := range merged {
+		index[note.ID] = i
+	}
+
+	// upToDate holds notes the client already has the final copy of,
+	// resend holds notes the client sent but where the server copy was kept.
+	upToDate := make(map[string]bool, len(clientNotes))
+	resend := make(map[string]bool)
+
+	for _, clientNote := range clientNotes {
+		if clientNote.ID == "" {
+			continue
+		}
+
+		i, exists := index[clientNote.ID]
+		if !exists {
+			clientNote.LastSync = syncTime
+			index[clientNote.ID] = len(merged)
+			merged = append(merged, clientNote)
+			upToDate[clientNote.ID] = true
+			continue
+		}
+
+		serverNote := merged[i]
+		serverChanged := serverNote.LastSync.After(lastSync)
+		clientChanged := clientNote.ModifiedAt.After(lastSync)
+
+		if !clientChanged || serverChanged {
+			if sameRevision(serverNote, clientNote) {
+				upToDate[clientNote.ID] = true
+			} else {
+				resend[clientNote.ID] = true
+			}
+			continue
+		}
+
+		clientNote.LastSync = syncTime
+		merged[i] = clientNote
+		upToDate[clientNote.ID] = true
+	}
+
+	var missing []local.Note
+	for _, note := range merged {
+		if upToDate[note.ID] {
+			continue
+		}
+		if resend[note.ID] || lastSync.IsZero() || note.LastSync.After(lastSync) {
+			missing = append(missing, note)
+		}
+	}
+
+	return merged, missing
+}
+
+func sameRevision(a, b local.Note) bool {
+	return a.Version == b.Version && a.ModifiedAt.Equal(b.ModifiedAt)
 }
 
 func generateSyncCode() string {
@@ -130,6 +237,7 @@ func (s *Server) GetNotesHandler(w http.ResponseWriter, r *http.Request) {
 	// Lock Master Lock and Release it when we're done checking
 	s.mu.RLock()
 	user, exists := s.users[syncCode]
+	userLock := s.userLocks[syncCode]
 	s.mu.RUnlock()
 
 	if !exists {
@@ -138,7 +246,9 @@ func (s *Server) GetNotesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Map the bytes from the server into a user readable json output
+	userLock.RLock()
 	res := map[string]any{"notes": user.Notes}
+	userLock.RUnlock()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(res)
 }
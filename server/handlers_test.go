@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+)
+
+func newTestServer(t *testing.T) (*Server, string) {
+	srv, err := NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.RegisterHandler(rec, httptest.NewRequest(http.MethodPost, "/api/register", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected register to succeed, got %d", rec.Code)
+	}
+
+	var res map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode register response: %v", err)
+	}
+
+	return srv, res["syncCode"]
+}
+
+func doSync(t *testing.T, srv *Server, syncCode string, req SyncRequest) SyncResponse {
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Failed to marshal sync request: %v", err)
+	}
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/sync", bytes.NewReader(body))
+	httpReq.Header.Set("X-Sync-Code", syncCode)
+	rec := httptest.NewRecorder()
+	srv.SyncHandler(rec, httpReq)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected sync to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var res SyncResponse
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("Failed to decode sync response: %v", err)
+	}
+
+	return res
+}
+
+func TestSyncHandler_PropagatesNotes(t *testing.T) {
+	srv, syncCode := newTestServer(t)
+
+	note := local.Note{
+		ID:         "note-1",
+		Name:       "first",
+		Content:    "from device a",
+		CreatedAt:  time.Now(),
+		ModifiedAt: time.Now(),
+	}
+
+	first := doSync(t, srv, syncCode, SyncRequest{Notes: []local.Note{note}})
+	if len(first.Notes) != 0 {
+		t.Errorf("Expected no missing notes for device a, got %d", len(first.Notes))
+	}
+
+	second := doSync(t, srv, syncCode, SyncRequest{})
+	if len(second.Notes) != 1 || second.Notes[0].Content != note.Content {
+		t.Fatalf("Expected device b to receive the note, got %v", second.Notes)
+	}
+
+	// Nothing changed since device b's sync, so there's nothing to send
+	third := doSync(t, srv, syncCode, SyncRequest{LastSync: second.SyncTime})
+	if len(third.Notes) != 0 {
+		t.Errorf("Expected no changes since last sync, got %d", len(third.Notes))
+	}
+}
+
+func TestSyncHandler_ConflictKeepsServerCopy(t *testing.T) {
+	srv, syncCode := newTestServer(t)
+
+	base := local.Note{
+		ID:         "note-1",
+		Name:       "shared",
+		Content:    "base",
+		CreatedAt:  time.Now(),
+		ModifiedAt: time.Now(),
+		Version:    1,
+	}
+	res := doSync(t, srv, syncCode, SyncRequest{Notes: []local.Note{base}})
+	lastSync := res.SyncTime
+
+	// Device a pushes a newer version first
+	serverEdit := base
+	serverEdit.Content = "edited on a"
+	serverEdit.Version = 3
+	serverEdit.ModifiedAt = time.Now().Add(time.Second)
+	doSync(t, srv, syncCode, SyncRequest{Notes: []local.Note{serverEdit}, LastSync: lastSync})
+
+	// Device b edited the same note from the same base, even a higher version
+	// doesn't win since the client has to resolve the conflict itself
+	clientEdit := base
+	clientEdit.Content = "edited on b"
+	clientEdit.Version = 4
+	clientEdit.ModifiedAt = time.Now().Add(2 * time.Second)
+	res = doSync(t, srv, syncCode, SyncRequest{Notes: []local.Note{clientEdit}, LastSync: lastSync})
+
+	if len(res.Notes) != 1 || res.Notes[0].Content != serverEdit.Content {
+		t.Fatalf("Expected server copy to be kept and returned, got %v", res.Notes)
+	}
+
+	if got := srv.users[syncCode].Notes[0].Content; got != serverEdit.Content {
+		t.Errorf("Expected stored content %q, got %q", serverEdit.Content, got)
+	}
+}
+
+func TestSyncHandler_RejectsUnknownUser(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sync", bytes.NewReader([]byte(`{"notes":[]}`)))
+	req.Header.Set("X-Sync-Code", "NOPE NOPE NOPE NOPE")
+	rec := httptest.NewRecorder()
+	srv.SyncHandler(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown sync code, got %d", rec.Code)
+	}
+}
@@ -92,6 +92,7 @@ func (s *Server) saveUserToDisk(syncCode string) error {
 	s.mu.RLock()
 	user, userExists := s.users[syncCode]
 	userLock, lockExists := s.userLocks[syncCode]
+	s.mu.RUnlock()
 
 	if !userExists || !lockExists {
 		return fmt.Errorf("user %s not found", syncCode)
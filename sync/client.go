@@ -1 +1,329 @@
+// Package sync is the client side of biji-server. It pushes local changes,
+// pulls the ones made on other devices and applies them to a local.Store.
 package sync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/server"
+)
+
+var (
+	// ErrUnauthorized is returned when the request had no sync code or the server refused it.
+	ErrUnauthorized = errors.New("sync code missing or rejected by server")
+	// ErrNotFound is returned when the server has no user for the sync code.
+	ErrNotFound = errors.New("sync code is not registered on the server")
+)
+
+// ServerError is any other non 2xx response, StatusCode tells 4xx and 5xx apart.
+type ServerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server responded %d: %s", e.StatusCode, e.Message)
+}
+
+// Client talks to a biji-server. The zero value is not usable, create one with NewClient.
+type Client struct {
+	BaseURL  string
+	SyncCode string
+
+	HTTPClient *http.Client
+	MaxRetries int           // retries after the first attempt, only for network errors and 5xx
+	Backoff    time.Duration // wait before the first retry, doubled on every retry after
+
+	Strategy Strategy // settles notes changed on both sides, defaults to LastWriterWins
+}
+
+// Result reports what a Sync did. Conflicts holds the ones the strategy
+// couldn't settle, they are also saved for the user to look at.
+type Result struct {
+	Pushed    int
+	Pulled    int
+	Resolved  int
+	Conflicts []Conflict
+	SyncTime  time.Time
+}
+
+// NewClient returns a Client for the server at baseURL with sensible timeouts.
+// syncCode can be empty when the client is only going to Register.
+func NewClient(baseURL, syncCode string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		SyncCode:   syncCode,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+		MaxRetries: 3,
+		Backoff:    500 * time.Millisecond,
+		Strategy:   LastWriterWins{},
+	}
+}
+
+// Register creates a new user on the server and stores the returned sync code on the client.
+// Unlike the other requests it isn't retried.
+func (c *Client) Register(ctx context.Context) (string, error) {
+	var res map[string]string
+	// Sent once, if the server made the user before failing a retry would make another
+	if err := c.attempt(ctx, http.MethodPost, "/api/register", nil, &res); err != nil {
+		return "", fmt.Errorf("error registering with server: %w", err)
+	}
+
+	syncCode := res["syncCode"]
+	if syncCode == "" {
+		return "", fmt.Errorf("server did not return a sync code")
+	}
+	c.SyncCode = syncCode
+
+	return syncCode, nil
+}
+
+// Pull fetches every note the server holds for the sync code.
+func (c *Client) Pull(ctx context.Context) ([]local.Note, error) {
+	var res struct {
+		Notes []local.Note `json:"notes"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/notes", nil, &res); err != nil {
+		return nil, fmt.Errorf("error pulling notes: %w", err)
+	}
+
+	return res.Notes, nil
+}
+
+// Push sends the notes changed since lastSync and returns the server's answer,
+// which holds the notes the client is missing and the time the server synced at.
+func (c *Client) Push(ctx context.Context, notes []local.Note, lastSync time.Time) (*server.SyncResponse, error) {
+	req := server.SyncRequest{Notes: notes}
+	if notes == nil {
+		req.Notes = []local.Note{}
+	}
+	if !lastSync.IsZero() {
+		req.LastSync = lastSync.UTC().Format(time.RFC3339Nano)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling sync request: %w", err)
+	}
+
+	var res server.SyncResponse
+	if err := c.do(ctx, http.MethodPost, "/api/sync", body, &res); err != nil {
+		return nil, fmt.Errorf("error pushing notes: %w", err)
+	}
+
+	return &res, nil
+}
+
+// Sync pushes the store's pending notes, then applies whatever the server sent back.
+// Notes changed on both sides go through the client's Strategy, and anything the strategy
+// changed is pushed in a second round so the server ends up with the resolved notes.
+func (c *Client) Sync(ctx context.Context, s *local.Store) (*Result, error) {
+	base, err := loadBase(s.Dir())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+
+	// The second round only runs when resolving conflicts left notes to push
+	for round := 0; round < 2; round++ {
+		pending := s.PendingSync()
+		if round > 0 && len(pending) == 0 {
+			break
+		}
+
+		res, err := c.Push(ctx, pending, s.LastSynced())
+		if err != nil {
+			return nil, err
+		}
+
+		syncTime, err := time.Parse(time.RFC3339Nano, res.SyncTime)
+		if err != nil {
+			return nil, fmt.Errorf("server returned invalid sync time %q: %w", res.SyncTime, err)
+		}
+
+		clean, resolved, conflicts, err := c.settle(s, base, res.Notes, syncTime)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.MergeRemote(pending, clean, syncTime); err != nil {
+			return nil, fmt.Errorf("error applying synced notes: %w", err)
+		}
+		if err := s.UpsertNotes(resolved); err != nil {
+			return nil, fmt.Errorf("error saving resolved notes: %w", err)
+		}
+
+		result.Pushed += len(pending)
+		result.Pulled += len(res.Notes)
+		result.Resolved += len(resolved)
+		result.Conflicts = append(result.Conflicts, conflicts...)
+		result.SyncTime = syncTime
+	}
+
+	if err := saveBase(s.Dir(), s, base); err != nil {
+		return nil, err
+	}
+	if err := recordConflicts(s.Dir(), result.Conflicts); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// settle sorts the notes the server sent into ones that can be applied as they are and ones
+// a strategy had to resolve. Resolved notes are stamped as modified after syncTime so they
+// go out again on the next push.
+func (c *Client) settle(s *local.Store, base map[string]local.Note, remote []local.Note, syncTime time.Time) (clean, resolved []local.Note, unresolved []Conflict, err error) {
+	strategy := c.Strategy
+	if strategy == nil {
+		strategy = LastWriterWins{}
+	}
+
+	for _, remoteNote := range remote {
+		var localPtr, basePtr *local.Note
+		if note, err := s.GetNoteFromID(remoteNote.ID); err == nil {
+			localPtr = &note
+		} else if note, err := s.GetTrashedNote(remoteNote.ID); err == nil {
+			localPtr = &note
+		}
+		if note, ok := base[remoteNote.ID]; ok {
+			basePtr = &note
+		}
+
+		var notes []local.Note
+		if conflict := Detect(basePtr, localPtr, &remoteNote); conflict != nil {
+			resolution, err := strategy.Resolve(*conflict)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error resolving conflict on %s: %w", conflict.Name(), err)
+			}
+			if resolution.Unresolved {
+				unresolved = append(unresolved, *conflict)
+			}
+			notes = resolution.Notes
+		} else if merged, ok := mergeFields(basePtr, localPtr, &remoteNote); ok {
+			notes = []local.Note{merged}
+		} else if isDeleted(localPtr) && localPtr != nil && localPtr.ModifiedAt.After(localPtr.LastSync) {
+			// Deleted here and untouched there, keep the tombstone so it goes out on the push
+			continue
+		} else {
+			clean = append(clean, remoteNote)
+			continue
+		}
+
+		for _, note := range notes {
+			// Taking the remote note as it is needs no push
+			if note.ID == remoteNote.ID && sameNote(note, remoteNote) {
+				clean = append(clean, remoteNote)
+				continue
+			}
+
+			note.Version = max(note.Version, remoteNote.Version) + 1
+			note.ModifiedAt = time.Now()
+			if !note.ModifiedAt.After(syncTime) {
+				note.ModifiedAt = syncTime.Add(time.Millisecond)
+			}
+			if note.ID == remoteNote.ID {
+				note.LastSync = remoteNote.LastSync
+			}
+			resolved = append(resolved, note)
+		}
+	}
+
+	return clean, resolved, unresolved, nil
+}
+
+// do sends a request and decodes the JSON response into out. Network errors and 5xx
+// responses are retried with exponential backoff, everything else fails right away.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	wait := c.Backoff
+	var lastErr error
+
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+
+		err := c.attempt(ctx, method, path, body, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, body []byte, out any) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("error building request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.SyncCode != "" {
+		req.Header.Set("X-Sync-Code", c.SyncCode)
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case res.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return &ServerError{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding server response: %w", err)
+	}
+
+	return nil
+}
+
+func retryable(err error) bool {
+	if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrNotFound) {
+		return false
+	}
+
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.StatusCode >= 500
+	}
+
+	// Transport errors from the http client are worth another try, bad responses are not
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,297 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/dallas1295/biji/server"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	srv, err := server.NewServer(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/register", srv.RegisterHandler)
+	mux.HandleFunc("/api/sync", srv.SyncHandler)
+	mux.HandleFunc("/api/notes", srv.GetNotesHandler)
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func newTestStore(t *testing.T) *local.Store {
+	store := &local.Store{}
+	if err := store.InitAt(t.TempDir()); err != nil {
+		t.Fatalf("Failed to create test store: %v", err)
+	}
+	return store
+}
+
+func TestClientSync_BetweenTwoStores(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	deviceA := NewClient(ts.URL, "")
+	syncCode, err := deviceA.Register(ctx)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	deviceB := NewClient(ts.URL, syncCode)
+
+	storeA := newTestStore(t)
+	storeB := newTestStore(t)
+
+	if _, err := storeA.AddNote("shared", "written on a"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	res, err := deviceA.Sync(ctx, storeA)
+	if err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if res.Pushed != 1 {
+		t.Errorf("Expected 1 pushed note, got %d", res.Pushed)
+	}
+	if len(storeA.PendingSync()) != 0 {
+		t.Error("Expected no pending notes after sync")
+	}
+
+	res, err = deviceB.Sync(ctx, storeB)
+	if err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+	if res.Pulled != 1 || len(storeB.Notes) != 1 || storeB.Notes[0].Content != "written on a" {
+		t.Fatalf("Expected device b to pull the note, got %v", storeB.Notes)
+	}
+
+	pulled, err := deviceB.Pull(ctx)
+	if err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if len(pulled) != 1 {
+		t.Errorf("Expected 1 note on the server, got %d", len(pulled))
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	_, err := NewClient(ts.URL, "").Pull(ctx)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected ErrUnauthorized without a sync code, got %v", err)
+	}
+
+	_, err = NewClient(ts.URL, "NOPE NOPE NOPE NOPE").Pull(ctx)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown sync code, got %v", err)
+	}
+}
+
+func TestClient_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"notes":[]}`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, "CODE")
+	client.Backoff = time.Millisecond
+
+	if _, err := client.Pull(context.Background()); err != nil {
+		t.Fatalf("Expected pull to succeed after retries, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+
+	client.MaxRetries = 0
+	calls.Store(0)
+	_, err := client.Pull(context.Background())
+
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) || serverErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected a 503 ServerError, got %v", err)
+	}
+}
+
+func TestClientRegister_NoRetries(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, "")
+	client.Backoff = time.Millisecond
+
+	if _, err := client.Register(context.Background()); err == nil {
+		t.Fatal("Expected register to fail")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected register to be sent once, got %d attempts", calls.Load())
+	}
+}
+
+func TestClientSync_PropagatesDeletes(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	deviceA := NewClient(ts.URL, "")
+	syncCode, err := deviceA.Register(ctx)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	deviceB := NewClient(ts.URL, syncCode)
+
+	storeA := newTestStore(t)
+	storeB := newTestStore(t)
+
+	note, err := storeA.AddNote("doomed", "soon gone")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	for _, step := range []struct {
+		client *Client
+		store  *local.Store
+	}{{deviceA, storeA}, {deviceB, storeB}} {
+		if _, err := step.client.Sync(ctx, step.store); err != nil {
+			t.Fatalf("Failed to sync: %v", err)
+		}
+	}
+
+	if err := storeA.DeleteNote(note.ID); err != nil {
+		t.Fatalf("Failed to delete note: %v", err)
+	}
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if len(storeA.Notes) != 0 || len(storeA.Trash) != 1 {
+		t.Fatalf("Expected the delete to survive the sync, got %d notes and %d trashed", len(storeA.Notes), len(storeA.Trash))
+	}
+
+	if _, err := deviceB.Sync(ctx, storeB); err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+	if len(storeB.Notes) != 0 || len(storeB.Trash) != 1 {
+		t.Errorf("Expected device b to trash the note, got %d notes and %d trashed", len(storeB.Notes), len(storeB.Trash))
+	}
+}
+
+func TestClientSync_PurgeWaitsForDelete(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	deviceA := NewClient(ts.URL, "")
+	syncCode, err := deviceA.Register(ctx)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	deviceB := NewClient(ts.URL, syncCode)
+
+	storeA := newTestStore(t)
+	storeB := newTestStore(t)
+
+	note, err := storeA.AddNote("doomed", "soon gone")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if _, err := deviceB.Sync(ctx, storeB); err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+
+	// Deleted and purged before the deletion was pushed
+	if err := storeA.DeleteNote(note.ID); err != nil {
+		t.Fatalf("Failed to delete note: %v", err)
+	}
+	n, err := storeA.EmptyTrash()
+	if err != nil {
+		t.Fatalf("Failed to empty trash: %v", err)
+	}
+	if n != 0 || len(storeA.Trash) != 1 {
+		t.Fatalf("Expected the unsynced deletion kept, got %d purged and %d trashed", n, len(storeA.Trash))
+	}
+
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if n, err := storeA.EmptyTrash(); err != nil || n != 1 {
+		t.Fatalf("Expected the synced deletion purged, got %d: %v", n, err)
+	}
+
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if _, err := deviceB.Sync(ctx, storeB); err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+	if _, err := storeA.FindNoteID("doomed"); err == nil {
+		t.Error("Expected the purged note to stay gone on device a")
+	}
+	if _, err := storeB.FindNoteID("doomed"); err == nil {
+		t.Error("Expected device b to get the deletion")
+	}
+}
+
+func TestClientSync_PushesRenameOfClashingName(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	deviceA := NewClient(ts.URL, "")
+	syncCode, err := deviceA.Register(ctx)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	deviceB := NewClient(ts.URL, syncCode)
+
+	storeA := newTestStore(t)
+	storeB := newTestStore(t)
+
+	// Both devices make a todo before they ever sync
+	fromA, err := storeA.AddNote("todo", "from a")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := storeB.AddNote("todo", "from b"); err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if _, err := deviceB.Sync(ctx, storeB); err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+	renamed, err := storeB.GetNoteFromID(fromA.ID)
+	if err != nil || renamed.Name != "todo (2)" {
+		t.Fatalf("Expected a's todo renamed on device b, got %q: %v", renamed.Name, err)
+	}
+
+	// The rename went out with the same sync
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	onA, err := storeA.GetNoteFromID(fromA.ID)
+	if err != nil || onA.Name != "todo (2)" {
+		t.Errorf("Expected the rename to reach device a, got %q: %v", onA.Name, err)
+	}
+}
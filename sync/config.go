@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dallas1295/biji/local"
+)
+
+const configFile = "sync.json"
+
+// ErrNotLinked is returned by LoadConfig when this device isn't attached to a server.
+var ErrNotLinked = errors.New("not linked to a sync server, run biji sync register or biji sync link")
+
+// Config is what a device needs to reach its server. It lives in the biji
+// config directory next to biji.json.
+type Config struct {
+	Server   string `json:"server"`
+	SyncCode string `json:"syncCode"`
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// LoadConfig reads the sync config from dir, or returns ErrNotLinked if there is none.
+func LoadConfig(dir string) (*Config, error) {
+	var config Config
+	if err := readJSON(filepath.Join(dir, configFile), &config); err != nil {
+		return nil, fmt.Errorf("error loading sync config: %w", err)
+	}
+
+	if config.Server == "" || config.SyncCode == "" {
+		return nil, ErrNotLinked
+	}
+
+	return &config, nil
+}
+
+// Save writes the config to dir, it holds the sync code so only the user can read it.
+// It's never readable by anyone else, not even while it's written.
+func (c *Config) Save(dir string) error {
+	if err := writeJSON(filepath.Join(dir, configFile), c, 0o600); err != nil {
+		return fmt.Errorf("error saving sync config: %w", err)
+	}
+
+	return nil
+}
+
+// Client returns a Client for the configured server and strategy.
+func (c *Config) Client() (*Client, error) {
+	strategy, err := ParseStrategy(c.Strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	client := NewClient(c.Server, c.SyncCode)
+	client.Strategy = strategy
+
+	return client, nil
+}
+
+// Unlink forgets the server. The sync base and when each note was last synced go too
+// since they only make sense against that server, so linking to another one starts with
+// a full sync. Unresolved conflicts stay since they are about local notes.
+func Unlink(s *local.Store) error {
+	for _, name := range []string{configFile, baseFile} {
+		err := os.Remove(filepath.Join(s.Dir(), name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error unlinking sync server: %w", err)
+		}
+	}
+
+	if err := s.ForgetSync(); err != nil {
+		return fmt.Errorf("error unlinking sync server: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestConfigSave_OnlyUserCanRead(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows doesn't have Unix permissions")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, configFile)
+	// A config saved by an older version that left it readable
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("Failed to write old config: %v", err)
+	}
+
+	config := &Config{Server: "http://localhost:8080", SyncCode: "secret"}
+	if err := config.Save(dir); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat config: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected the config to be 0600, got %o", perm)
+	}
+
+	loaded, err := LoadConfig(dir)
+	if err != nil || loaded.SyncCode != "secret" {
+		t.Errorf("Expected the config back, got %+v: %v", loaded, err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the config in the directory, got %d files", len(entries))
+	}
+}
+
+func TestUnlink_NextServerGetsEveryNote(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	for _, name := range []string{"kept", "doomed"} {
+		if _, err := store.AddNote(name, ""); err != nil {
+			t.Fatalf("Failed to add note: %v", err)
+		}
+	}
+
+	first := NewClient(newTestServer(t).URL, "")
+	if _, err := first.Register(ctx); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	if _, err := first.Sync(ctx, store); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	if err := Unlink(store); err != nil {
+		t.Fatalf("Failed to unlink: %v", err)
+	}
+	if !store.LastSynced().IsZero() {
+		t.Errorf("Expected no sync time after unlinking, got %v", store.LastSynced())
+	}
+
+	second := NewClient(newTestServer(t).URL, "")
+	if _, err := second.Register(ctx); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	res, err := second.Sync(ctx, store)
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	pulled, err := second.Pull(ctx)
+	if err != nil {
+		t.Fatalf("Failed to pull: %v", err)
+	}
+	if res.Pushed != 2 || len(pulled) != 2 {
+		t.Errorf("Expected both notes pushed to the new server, pushed %d and it has %d", res.Pushed, len(pulled))
+	}
+}
@@ -1 +1,450 @@
 package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+	"github.com/google/uuid"
+)
+
+// ConflictKind classifies what both sides did to a note since the last sync.
+type ConflictKind int
+
+const (
+	EditEdit     ConflictKind = iota // both sides changed the content
+	EditDelete                       // one side deleted a note the other side changed
+	RenameRename                     // both sides renamed the note to different names
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case EditEdit:
+		return "edit/edit"
+	case EditDelete:
+		return "edit/delete"
+	case RenameRename:
+		return "rename/rename"
+	default:
+		return "unknown"
+	}
+}
+
+// Conflict is a note both sides touched since the last sync. Base is the note as of
+// the last sync and is nil when it isn't known. Local or Remote is nil or has DeletedAt set
+// when that side deleted it.
+type Conflict struct {
+	Kind       ConflictKind `json:"kind"`
+	NoteID     string       `json:"noteId"`
+	Base       *local.Note  `json:"base,omitempty"`
+	Local      *local.Note  `json:"local,omitempty"`
+	Remote     *local.Note  `json:"remote,omitempty"`
+	DetectedAt time.Time    `json:"detectedAt"`
+}
+
+// Name returns the name the note is best known by, for showing the conflict to the user.
+func (c Conflict) Name() string {
+	switch {
+	case c.Local != nil && !isDeleted(c.Remote):
+		return c.Local.Name
+	case c.Remote != nil:
+		return c.Remote.Name
+	case c.Base != nil:
+		return c.Base.Name
+	default:
+		return c.NoteID
+	}
+}
+
+// Resolution is what a Strategy made of a conflict. Notes are stored locally and
+// pushed on the next sync. Unresolved means the user still has to look at it,
+// for example because conflict markers were left in the content.
+type Resolution struct {
+	Notes      []local.Note
+	Unresolved bool
+}
+
+// Strategy settles a conflict.
+type Strategy interface {
+	Resolve(c Conflict) (Resolution, error)
+}
+
+// Detect compares a note against the base from the last sync and returns a Conflict when
+// both sides changed it, or nil when there is nothing to settle. A delete on one side only
+// clashes with an edit on the other. When there is no base a local note counts as changed
+// if it was modified after its LastSync.
+func Detect(base, localNote, remote *local.Note) *Conflict {
+	if remote == nil {
+		return nil
+	}
+
+	conflict := &Conflict{
+		NoteID:     remote.ID,
+		Base:       base,
+		Local:      localNote,
+		Remote:     remote,
+		DetectedAt: time.Now(),
+	}
+
+	// A note purged here that the other side kept working on
+	if localNote == nil {
+		if base == nil || isDeleted(remote) || sameNote(*base, *remote) {
+			return nil
+		}
+		conflict.Kind = EditDelete
+		return conflict
+	}
+
+	if sameNote(*localNote, *remote) {
+		return nil
+	}
+
+	localChanged := localNote.ModifiedAt.After(localNote.LastSync)
+	remoteChanged := true
+	if base != nil {
+		localChanged = !sameNote(*base, *localNote)
+		remoteChanged = !sameNote(*base, *remote)
+	}
+	if !localChanged || !remoteChanged {
+		return nil
+	}
+
+	switch {
+	case isDeleted(localNote) && isDeleted(remote):
+		return nil
+	case isDeleted(localNote) || isDeleted(remote):
+		conflict.Kind = EditDelete
+	case bothChanged(base, localNote.Content, remote.Content, func(n *local.Note) string { return n.Content }):
+		conflict.Kind = EditEdit
+	case bothChanged(base, localNote.Name, remote.Name, func(n *local.Note) string { return n.Name }):
+		conflict.Kind = RenameRename
+	default:
+		// The sides changed different fields, mergeFields puts them together
+		return nil
+	}
+
+	return conflict
+}
+
+func isDeleted(note *local.Note) bool {
+	return note == nil || !note.DeletedAt.IsZero()
+}
+
+// bothChanged reports whether both sides moved a field away from the base to different values.
+func bothChanged(base *local.Note, localValue, remoteValue string, field func(*local.Note) string) bool {
+	if localValue == remoteValue {
+		return false
+	}
+	if base == nil {
+		return true
+	}
+	return field(base) != localValue && field(base) != remoteValue
+}
+
+// mergeFields combines a name change or move on one side with a content change on the other,
+// and merges tags added or removed on either side.
+// It reports false when there is nothing local to keep and the remote note applies as is.
+func mergeFields(base, localNote, remote *local.Note) (local.Note, bool) {
+	if base == nil || isDeleted(localNote) || isDeleted(remote) ||
+		sameNote(*localNote, *remote) || sameNote(*base, *localNote) {
+		return local.Note{}, false
+	}
+
+	merged := *remote
+	if localNote.Name != base.Name {
+		merged.Name = localNote.Name
+	}
+	if localNote.Content != base.Content {
+		merged.Content = localNote.Content
+	}
+	if localNote.Notebook != base.Notebook {
+		merged.Notebook = localNote.Notebook
+	}
+	merged.Tags = mergeTags(base.Tags, localNote.Tags, remote.Tags)
+	mergeFlags(&merged, *base, *localNote)
+
+	return merged, !sameNote(merged, *remote)
+}
+
+// mergeFlags keeps a pin, unpin or favorite made locally since base.
+func mergeFlags(merged *local.Note, base, localNote local.Note) {
+	if localNote.Pinned != base.Pinned || localNote.Position != base.Position {
+		merged.Pinned, merged.Position = localNote.Pinned, localNote.Position
+	}
+	if localNote.Favorite != base.Favorite {
+		merged.Favorite = localNote.Favorite
+	}
+}
+
+// mergeTags applies the tags added and removed locally since base on top of the remote tags.
+func mergeTags(base, localTags, remote []string) []string {
+	merged := slices.DeleteFunc(slices.Clone(remote), func(tag string) bool {
+		return slices.Contains(base, tag) && !slices.Contains(localTags, tag)
+	})
+	for _, tag := range localTags {
+		if !slices.Contains(base, tag) && !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+
+	slices.Sort(merged)
+	return merged
+}
+
+func sameNote(a, b local.Note) bool {
+	return a.Name == b.Name && a.Content == b.Content && a.Notebook == b.Notebook && slices.Equal(a.Tags, b.Tags) &&
+		a.Pinned == b.Pinned && a.Position == b.Position && a.Favorite == b.Favorite &&
+		a.DeletedAt.IsZero() == b.DeletedAt.IsZero()
+}
+
+// LastWriterWins keeps whichever side was modified last, and the higher Version when both
+// were modified at the same moment. How many edits a side made doesn't count.
+// An edit always beats a delete so no work is thrown away.
+type LastWriterWins struct{}
+
+func (LastWriterWins) Resolve(c Conflict) (Resolution, error) {
+	if edit, ok := keepEdit(c); ok {
+		return edit, nil
+	}
+
+	winner := *c.Remote
+	if c.Local.ModifiedAt.After(c.Remote.ModifiedAt) ||
+		(c.Local.ModifiedAt.Equal(c.Remote.ModifiedAt) && c.Local.Version > c.Remote.Version) {
+		winner = *c.Local
+	}
+
+	return Resolution{Notes: []local.Note{winner}}, nil
+}
+
+// KeepBoth keeps the remote note as it is and saves the local side as a new note
+// named after the original, nothing is merged or lost.
+type KeepBoth struct{}
+
+func (KeepBoth) Resolve(c Conflict) (Resolution, error) {
+	if edit, ok := keepEdit(c); ok {
+		return edit, nil
+	}
+
+	copied := *c.Local
+	copied.ID = uuid.NewString()
+	copied.Name = c.Local.Name + " (conflicted copy)"
+	copied.Version = 0
+	copied.LastSync = time.Time{}
+
+	return Resolution{Notes: []local.Note{*c.Remote, copied}}, nil
+}
+
+// ThreeWayMerge merges the content line by line against the base. Lines changed on only
+// one side are taken as they are, lines changed on both sides are wrapped in conflict
+// markers and the conflict stays unresolved until the user cleans them up.
+type ThreeWayMerge struct{}
+
+func (ThreeWayMerge) Resolve(c Conflict) (Resolution, error) {
+	// Nothing to merge against a delete, keep the edit
+	if edit, ok := keepEdit(c); ok {
+		return edit, nil
+	}
+
+	var base local.Note
+	if c.Base != nil {
+		base = *c.Base
+	}
+
+	merged := *c.Remote
+	content, clean := Merge3(base.Content, c.Local.Content, c.Remote.Content)
+	merged.Content = content
+	merged.Tags = mergeTags(base.Tags, c.Local.Tags, c.Remote.Tags)
+
+	// A rename or move on one side is kept, on both sides the local one wins
+	if c.Local.Name != base.Name {
+		merged.Name = c.Local.Name
+	}
+	if c.Local.Notebook != base.Notebook {
+		merged.Notebook = c.Local.Notebook
+	}
+	mergeFlags(&merged, base, *c.Local)
+
+	return Resolution{Notes: []local.Note{merged}, Unresolved: !clean}, nil
+}
+
+// keepEdit settles a conflict where one side deleted the note by keeping the side that
+// edited it, restored from the trash if need be. It reports false when neither side deleted.
+func keepEdit(c Conflict) (Resolution, bool) {
+	localDeleted, remoteDeleted := isDeleted(c.Local), isDeleted(c.Remote)
+	if !localDeleted && !remoteDeleted {
+		return Resolution{}, false
+	}
+
+	switch {
+	case !localDeleted:
+		return Resolution{Notes: []local.Note{*c.Local}}, true
+	case !remoteDeleted:
+		return Resolution{Notes: []local.Note{*c.Remote}}, true
+	default:
+		// Both deleted, there is nothing to keep
+		return Resolution{}, true
+	}
+}
+
+// ParseStrategy maps the names used in the CLI to a Strategy.
+func ParseStrategy(name string) (Strategy, error) {
+	switch name {
+	case "", "last-writer-wins", "lww":
+		return LastWriterWins{}, nil
+	case "keep-both":
+		return KeepBoth{}, nil
+	case "merge":
+		return ThreeWayMerge{}, nil
+	default:
+		return nil, fmt.Errorf("unknown conflict strategy %q, use last-writer-wins, keep-both or merge", name)
+	}
+}
+
+const (
+	conflictsFile = "sync-conflicts.json"
+	baseFile      = "sync-base.json"
+)
+
+// LoadConflicts returns the unresolved conflicts saved in dir.
+func LoadConflicts(dir string) ([]Conflict, error) {
+	var conflicts []Conflict
+	if err := readJSON(filepath.Join(dir, conflictsFile), &conflicts); err != nil {
+		return nil, fmt.Errorf("error loading conflicts: %w", err)
+	}
+	return conflicts, nil
+}
+
+// SaveConflicts replaces the unresolved conflicts saved in dir.
+func SaveConflicts(dir string, conflicts []Conflict) error {
+	if err := writeJSON(filepath.Join(dir, conflictsFile), conflicts, 0o644); err != nil {
+		return fmt.Errorf("error saving conflicts: %w", err)
+	}
+	return nil
+}
+
+// MarkResolved drops the conflict for noteID from the ones saved in dir.
+func MarkResolved(dir, noteID string) error {
+	conflicts, err := LoadConflicts(dir)
+	if err != nil {
+		return err
+	}
+
+	kept := conflicts[:0]
+	found := false
+	for _, c := range conflicts {
+		if c.NoteID == noteID {
+			found = true
+			continue
+		}
+		kept = append(kept, c)
+	}
+
+	if !found {
+		return fmt.Errorf("no unresolved conflict for note %s", noteID)
+	}
+
+	return SaveConflicts(dir, kept)
+}
+
+// recordConflicts adds new conflicts to the saved ones, a newer conflict on
+// the same note replaces the older one.
+func recordConflicts(dir string, found []Conflict) error {
+	if len(found) == 0 {
+		return nil
+	}
+
+	conflicts, err := LoadConflicts(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range found {
+		replaced := false
+		for i := range conflicts {
+			if conflicts[i].NoteID == c.NoteID {
+				conflicts[i] = c
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	return SaveConflicts(dir, conflicts)
+}
+
+// loadBase reads the notes as they were after the last sync, keyed by ID.
+func loadBase(dir string) (map[string]local.Note, error) {
+	var notes []local.Note
+	if err := readJSON(filepath.Join(dir, baseFile), &notes); err != nil {
+		return nil, fmt.Errorf("error loading sync base: %w", err)
+	}
+
+	base := make(map[string]local.Note, len(notes))
+	for _, note := range notes {
+		base[note.ID] = note
+	}
+	return base, nil
+}
+
+// saveBase snapshots the notes that are in step with the server. Notes that are
+// still pending keep their previous base so the next sync can merge against it.
+func saveBase(dir string, s *local.Store, previous map[string]local.Note) error {
+	pending := make(map[string]bool)
+	for _, note := range s.PendingSync() {
+		pending[note.ID] = true
+	}
+
+	notes, err := s.GetNotes()
+	if err != nil {
+		return fmt.Errorf("error saving sync base: %w", err)
+	}
+
+	// Tombstones are part of the base too, so a later edit elsewhere is seen as one
+	notes = slices.Concat(notes, s.Trash)
+
+	synced := make([]local.Note, 0, len(notes))
+	for _, note := range notes {
+		if !pending[note.ID] {
+			synced = append(synced, note)
+		} else if base, ok := previous[note.ID]; ok {
+			synced = append(synced, base)
+		}
+	}
+
+	if err := writeJSON(filepath.Join(dir, baseFile), synced, 0o644); err != nil {
+		return fmt.Errorf("error saving sync base: %w", err)
+	}
+	return nil
+}
+
+// readJSON decodes path into v, a missing file leaves v untouched.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON writes v to path atomically, see local.WriteFileAtomic. The temp file is only
+// readable by the user until it gets perm.
+func writeJSON(path string, v any, perm os.FileMode) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return local.WriteFileAtomic(path, data, perm)
+}
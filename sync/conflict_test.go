@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dallas1295/biji/local"
+)
+
+func TestMerge3_Clean(t *testing.T) {
+	base := "one\ntwo\nthree\nfour"
+	localText := "one\ntwo changed here\nthree\nfour"
+	remoteText := "one\ntwo\nthree\nfour\nfive"
+
+	merged, clean := Merge3(base, localText, remoteText)
+	if !clean {
+		t.Fatalf("Expected a clean merge, got:\n%s", merged)
+	}
+
+	expected := "one\ntwo changed here\nthree\nfour\nfive"
+	if merged != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, merged)
+	}
+}
+
+func TestMerge3_ConflictMarkers(t *testing.T) {
+	base := "one\ntwo\nthree"
+	localText := "one\nlocal two\nthree"
+	remoteText := "one\nremote two\nthree"
+
+	merged, clean := Merge3(base, localText, remoteText)
+	if clean {
+		t.Fatal("Expected a conflict when both sides change the same line")
+	}
+
+	expected := strings.Join([]string{
+		"one",
+		markerLocal,
+		"local two",
+		markerSplit,
+		"remote two",
+		markerRemote,
+		"three",
+	}, "\n")
+	if merged != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, merged)
+	}
+}
+
+func TestDetect_Kinds(t *testing.T) {
+	base := local.Note{ID: "1", Name: "note", Content: "base"}
+
+	edited := func(name, content string) *local.Note {
+		note := base
+		note.Name = name
+		note.Content = content
+		return &note
+	}
+
+	tests := []struct {
+		name     string
+		local    *local.Note
+		remote   *local.Note
+		expected *ConflictKind
+	}{
+		{"only remote changed", edited("note", "base"), edited("note", "remote"), nil},
+		{"both edited", edited("note", "local"), edited("note", "remote"), ptr(EditEdit)},
+		{"both renamed", edited("local name", "base"), edited("remote name", "base"), ptr(RenameRename)},
+		{"rename and edit", edited("local name", "base"), edited("note", "remote"), nil},
+		{"deleted locally", nil, edited("note", "remote"), ptr(EditDelete)},
+	}
+
+	for _, tt := range tests {
+		conflict := Detect(&base, tt.local, tt.remote)
+		switch {
+		case tt.expected == nil && conflict != nil:
+			t.Errorf("%s: expected no conflict, got %s", tt.name, conflict.Kind)
+		case tt.expected != nil && conflict == nil:
+			t.Errorf("%s: expected %s conflict, got none", tt.name, *tt.expected)
+		case tt.expected != nil && conflict.Kind != *tt.expected:
+			t.Errorf("%s: expected %s conflict, got %s", tt.name, *tt.expected, conflict.Kind)
+		}
+	}
+}
+
+func TestMergeFields_Tags(t *testing.T) {
+	base := local.Note{ID: "1", Name: "note", Content: "base", Tags: []string{"a", "b"}}
+	localNote := base
+	localNote.Tags = []string{"a", "c"} // dropped b, added c
+	remote := base
+	remote.Tags = []string{"a", "b", "d"} // added d
+
+	merged, ok := mergeFields(&base, &localNote, &remote)
+	if !ok {
+		t.Fatal("Expected the tag changes to merge")
+	}
+	if !slices.Equal(merged.Tags, []string{"a", "c", "d"}) {
+		t.Errorf("Expected tags a c d, got %v", merged.Tags)
+	}
+}
+
+func TestMergeFields_Pins(t *testing.T) {
+	base := local.Note{ID: "1", Name: "note", Content: "base"}
+	localNote := base
+	localNote.Pinned, localNote.Position = true, 1
+	remote := base
+	remote.Content = "edited"
+	remote.Favorite = true
+
+	merged, ok := mergeFields(&base, &localNote, &remote)
+	if !ok {
+		t.Fatal("Expected the pin to merge")
+	}
+	if !merged.Pinned || merged.Position != 1 || !merged.Favorite || merged.Content != "edited" {
+		t.Errorf("Expected the local pin on top of the remote edit, got %+v", merged)
+	}
+}
+
+func TestLastWriterWins_LaterEditWins(t *testing.T) {
+	now := time.Now()
+	busy := &local.Note{ID: "1", Name: "note", Content: "many small edits", Version: 9, ModifiedAt: now.Add(-time.Hour)}
+	late := &local.Note{ID: "1", Name: "note", Content: "one late edit", Version: 2, ModifiedAt: now}
+
+	for _, c := range []Conflict{
+		{Kind: EditEdit, NoteID: "1", Local: busy, Remote: late},
+		{Kind: EditEdit, NoteID: "1", Local: late, Remote: busy},
+	} {
+		res, err := LastWriterWins{}.Resolve(c)
+		if err != nil {
+			t.Fatalf("Failed to resolve: %v", err)
+		}
+		if len(res.Notes) != 1 || res.Notes[0].Content != "one late edit" {
+			t.Errorf("Expected the later edit to win, got %+v", res.Notes)
+		}
+	}
+}
+
+func ptr(kind ConflictKind) *ConflictKind {
+	return &kind
+}
+
+func TestClientSync_ResolvesConflicts(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	deviceA := NewClient(ts.URL, "")
+	syncCode, err := deviceA.Register(ctx)
+	if err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+	deviceB := NewClient(ts.URL, syncCode)
+	deviceB.Strategy = ThreeWayMerge{}
+
+	storeA := newTestStore(t)
+	storeB := newTestStore(t)
+
+	note, err := storeA.AddNote("shared", "one\ntwo\nthree")
+	if err != nil {
+		t.Fatalf("Failed to add note: %v", err)
+	}
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	if _, err := deviceB.Sync(ctx, storeB); err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+
+	// Both devices edit different lines of the same note
+	time.Sleep(5 * time.Millisecond)
+	if _, err := storeA.UpdateNoteContent(note.ID, "one from a\ntwo\nthree"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+	if _, err := storeB.UpdateNoteContent(note.ID, "one\ntwo\nthree from b"); err != nil {
+		t.Fatalf("Failed to update note: %v", err)
+	}
+
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	res, err := deviceB.Sync(ctx, storeB)
+	if err != nil {
+		t.Fatalf("Failed to sync device b: %v", err)
+	}
+	if res.Resolved != 1 || len(res.Conflicts) != 0 {
+		t.Errorf("Expected one cleanly resolved conflict, got %d resolved and %d open", res.Resolved, len(res.Conflicts))
+	}
+
+	expected := "one from a\ntwo\nthree from b"
+	merged, err := storeB.GetNoteFromID(note.ID)
+	if err != nil || merged.Content != expected {
+		t.Fatalf("Expected merged content %q on device b, got %q (%v)", expected, merged.Content, err)
+	}
+
+	if _, err := deviceA.Sync(ctx, storeA); err != nil {
+		t.Fatalf("Failed to sync device a: %v", err)
+	}
+	merged, _ = storeA.GetNoteFromID(note.ID)
+	if merged.Content != expected {
+		t.Errorf("Expected device a to pull the merge, got %q", merged.Content)
+	}
+}
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	markerLocal  = "<<<<<<< local"
+	markerSplit  = "======="
+	markerRemote = ">>>>>>> remote"
+)
+
+// Merge3 is a line based three way merge of two edits of base. Hunks changed on one side
+// are taken from that side, hunks both sides changed differently are written between
+// conflict markers. It reports whether the merge was clean.
+func Merge3(base, localText, remoteText string) (string, bool) {
+	if localText == remoteText {
+		return localText, true
+	}
+	if localText == base {
+		return remoteText, true
+	}
+	if remoteText == base {
+		return localText, true
+	}
+
+	baseLines := splitLines(base)
+	localLines := splitLines(localText)
+	remoteLines := splitLines(remoteText)
+
+	localMatch := matchLines(baseLines, localLines)
+	remoteMatch := matchLines(baseLines, remoteLines)
+
+	var out []string
+	clean := true
+	i, l, r := 0, 0, 0
+
+	for i < len(baseLines) || l < len(localLines) || r < len(remoteLines) {
+		// All three agree on this line, copy it and move on
+		if i < len(baseLines) && localMatch[i] == l && remoteMatch[i] == r {
+			out = append(out, baseLines[i])
+			i, l, r = i+1, l+1, r+1
+			continue
+		}
+
+		// Find the next base line both sides kept, everything before it is one hunk
+		next, nextL, nextR := len(baseLines), len(localLines), len(remoteLines)
+		for k := i; k < len(baseLines); k++ {
+			if localMatch[k] >= 0 && remoteMatch[k] >= 0 {
+				next, nextL, nextR = k, localMatch[k], remoteMatch[k]
+				break
+			}
+		}
+
+		baseHunk := baseLines[i:next]
+		localHunk := localLines[l:nextL]
+		remoteHunk := remoteLines[r:nextR]
+
+		switch {
+		case slices.Equal(localHunk, baseHunk):
+			out = append(out, remoteHunk...)
+		case slices.Equal(remoteHunk, baseHunk), slices.Equal(localHunk, remoteHunk):
+			out = append(out, localHunk...)
+		default:
+			clean = false
+			out = append(out, markerLocal)
+			out = append(out, localHunk...)
+			out = append(out, markerSplit)
+			out = append(out, remoteHunk...)
+			out = append(out, markerRemote)
+		}
+
+		i, l, r = next, nextL, nextR
+	}
+
+	return strings.Join(out, "\n"), clean
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// matchLines pairs up the lines of a and b along their longest common subsequence.
+// The result holds, for each line of a, the index of its partner in b or -1.
+func matchLines(a, b []string) []int {
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// Common prefix and suffix are matched directly, which keeps the table small
+	// for the usual case of a few edits in a long note
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		match[start] = start
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+		match[endA] = endB
+	}
+
+	n, m := endA-start, endB-start
+	if n == 0 || m == 0 {
+		return match
+	}
+
+	// lcs[i][j] is the LCS length of a[start+i:endA] and b[start+j:endB]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[start+i] == b[start+j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[start+i] == b[start+j]:
+			match[start+i] = start + j
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+
+	return match
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// markdownStyle is the glamour style notes render with. It's picked once, asking the
+// terminal for its background on every render is slow and garbles the screen under bubbletea.
+var markdownStyle = sync.OnceValue(func() string {
+	switch {
+	case !term.IsTerminal(os.Stdout.Fd()):
+		return styles.NoTTYStyle
+	case lipgloss.HasDarkBackground():
+		return styles.DarkStyle
+	default:
+		return styles.LightStyle
+	}
+})
+
+// RenderMarkdown renders a note's Markdown for a terminal width columns wide, code blocks
+// highlighted by chroma. If it can't be rendered the content comes back as written.
+func RenderMarkdown(content string, width int) string {
+	if width <= 0 {
+		width = 80
+	}
+
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(markdownStyle()),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return content
+	}
+	out, err := r.Render(content)
+	if err != nil {
+		return content
+	}
+
+	return strings.Trim(out, "\n")
+}
@@ -0,0 +1,27 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	content := "# Plan\n\nsome words that go on long enough to need wrapping at twenty columns\n\n```go\nfunc main() {}\n```"
+
+	got := RenderMarkdown(content, 20)
+	for _, want := range []string{"Plan", "func main() {}"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the rendered note, got %q", want, got)
+		}
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if ansi.StringWidth(line) > 20 {
+			t.Errorf("Expected lines to wrap at 20 columns, got %q", line)
+		}
+	}
+	if strings.HasPrefix(got, "\n") || strings.HasSuffix(got, "\n") {
+		t.Errorf("Expected no blank lines around the note, got %q", got)
+	}
+}